@@ -0,0 +1,179 @@
+package audit
+
+import (
+	"errors"
+	"hr-backend-system/fieldcrypt"
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"log"
+	"slices"
+	"strings"
+)
+
+// Record appends entry to the audit log. The change it describes has
+// already been made, so a failed write is logged rather than returned.
+func Record(entry models.AuditEntry) {
+	if _, err := storage.Audit().AppendAuditEntry(entry); err != nil {
+		log.Printf("ERROR: failed to record audit entry %s for user %d: %v", entry.Action, entry.TargetID, err)
+	}
+}
+
+// List returns the entries matching filter with their personal data
+// decrypted. Personal data of erased users is listed as redacted.
+func List(filter models.AuditFilter) []models.AuditEntry {
+	entries := storage.Audit().ListAuditEntries(filter)
+	for i := range entries {
+		erased := subjectErased(entries[i].TargetID)
+		changes := make([]models.FieldChange, len(entries[i].Changes))
+		for j, change := range entries[i].Changes {
+			before, beforeErased := decrypt(entries[i].ID, change.Before, erased)
+			after, afterErased := decrypt(entries[i].ID, change.After, erased)
+			if beforeErased || afterErased {
+				changes[j] = models.FieldChange{Field: change.Field, Redacted: true}
+				continue
+			}
+			change.Before, change.After = before, after
+			changes[j] = change
+		}
+		entries[i].Changes = changes
+	}
+	return entries
+}
+
+// decrypt opens a sealed value of an entry. It reports true instead when
+// the value is personal data of an erased user, including values sealed
+// before per-user keys were introduced.
+func decrypt(entryID int, value any, erased bool) (any, bool) {
+	sealed, ok := value.(string)
+	if !ok {
+		return value, false
+	}
+
+	var plaintext string
+	var err error
+	switch {
+	case strings.HasPrefix(sealed, subjectPrefix):
+		plaintext, err = openPersonal(sealed)
+		if errors.Is(err, errErased) {
+			return nil, true
+		}
+	case fieldcrypt.IsEncrypted(sealed):
+		if erased {
+			return nil, true
+		}
+		plaintext, err = fieldcrypt.Decrypt(sealed)
+	default:
+		return value, false
+	}
+	if err != nil {
+		log.Printf("ERROR: failed to decrypt audit entry %d: %v", entryID, err)
+		return value, false
+	}
+	return plaintext, false
+}
+
+// Redact drops the values of changes, keeping which fields changed. It is
+// used when recording values would undo an erasure.
+func Redact(changes []models.FieldChange) []models.FieldChange {
+	redacted := make([]models.FieldChange, len(changes))
+	for i, change := range changes {
+		redacted[i] = models.FieldChange{Field: change.Field, Redacted: true}
+	}
+	return redacted
+}
+
+// UserChanges returns the fields that differ between two versions of a
+// user. before is nil for new accounts and after is nil for deleted ones.
+// Credentials are reported as changed without their values.
+func UserChanges(before, after *models.User) []models.FieldChange {
+	var b, a models.User
+	if before != nil {
+		b = *before
+	}
+	if after != nil {
+		a = *after
+	}
+
+	var changes []models.FieldChange
+	field := func(name string, old, new any) {
+		if old == new {
+			return
+		}
+		change := models.FieldChange{Field: name}
+		if before != nil {
+			change.Before = old
+		}
+		if after != nil {
+			change.After = new
+		}
+		changes = append(changes, change)
+	}
+	// Personal data is encrypted with the user's own key, see Shred
+	subjectID := b.ID
+	if after != nil {
+		subjectID = a.ID
+	}
+	personal := func(name string, old, new string) {
+		if old == new {
+			return
+		}
+		sealedOld, okOld := sealPersonal(subjectID, old)
+		sealedNew, okNew := sealPersonal(subjectID, new)
+		if !okOld || !okNew {
+			changes = append(changes, models.FieldChange{Field: name, Redacted: true})
+			return
+		}
+		change := models.FieldChange{Field: name}
+		if before != nil {
+			change.Before = sealedOld
+		}
+		if after != nil {
+			change.After = sealedNew
+		}
+		changes = append(changes, change)
+	}
+	secret := func(name string, changed bool) {
+		if changed {
+			changes = append(changes, models.FieldChange{Field: name, Redacted: true})
+		}
+	}
+
+	personal("name", b.Name, a.Name)
+	personal("email", b.Email, a.Email)
+	field("email_verified", b.EmailVerified, a.EmailVerified)
+	personal("pending_email", b.PendingEmail, a.PendingEmail)
+	personal("phone_number", b.PhoneNumber, a.PhoneNumber)
+	field("type", b.Type, a.Type)
+	field("two_factor_enabled", b.TwoFactorEnabled, a.TwoFactorEnabled)
+	secret("password", b.Password != a.Password)
+	secret("two_factor_secret", b.TOTPSecret != a.TOTPSecret)
+	secret("recovery_codes", !slices.Equal(b.RecoveryCodeHashes, a.RecoveryCodeHashes))
+	return changes
+}
+
+// SettingsChanges returns the settings that differ between two versions
+func SettingsChanges(before, after models.Settings) []models.FieldChange {
+	var changes []models.FieldChange
+	field := func(name string, old, new any) {
+		if old != new {
+			changes = append(changes, models.FieldChange{Field: name, Before: old, After: new})
+		}
+	}
+
+	field("require_email_verification", before.RequireEmailVerification, after.RequireEmailVerification)
+	field("require_admin_two_factor", before.RequireAdminTwoFactor, after.RequireAdminTwoFactor)
+	field("user_deletion_mode", before.UserDeletionMode, after.UserDeletionMode)
+	return changes
+}
+
+// RoleChanges returns the change of a role's permissions, nil if the set
+// is the same. The field is named after the role, e.g. operator.permissions.
+func RoleChanges(role string, before, after []string) []models.FieldChange {
+	b, a := slices.Clone(before), slices.Clone(after)
+	slices.Sort(b)
+	slices.Sort(a)
+	if slices.Equal(b, a) {
+		return nil
+	}
+	return []models.FieldChange{{Field: role + ".permissions", Before: b, After: a}}
+}
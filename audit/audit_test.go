@@ -0,0 +1,199 @@
+package audit
+
+import (
+	"hr-backend-system/fieldcrypt"
+	"hr-backend-system/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUserChanges(t *testing.T) {
+	base := models.User{
+		Name:               "Alice",
+		Email:              "alice@example.com",
+		Type:               models.UserTypeViewer,
+		Password:           "hash",
+		TOTPSecret:         "secret",
+		RecoveryCodeHashes: []string{"code"},
+	}
+	with := func(change func(*models.User)) *models.User {
+		u := base
+		change(&u)
+		return &u
+	}
+
+	tests := []struct {
+		name   string
+		before *models.User
+		after  *models.User
+		want   []models.FieldChange
+	}{
+		{"unchanged", &base, &base, nil},
+		{
+			"plain field",
+			&base, with(func(u *models.User) { u.Type = models.UserTypeOperator }),
+			[]models.FieldChange{{Field: "type", Before: models.UserTypeViewer, After: models.UserTypeOperator}},
+		},
+		{
+			"password",
+			&base, with(func(u *models.User) { u.Password = "new hash" }),
+			[]models.FieldChange{{Field: "password", Redacted: true}},
+		},
+		{
+			"two-factor secret",
+			&base, with(func(u *models.User) { u.TOTPSecret = "" }),
+			[]models.FieldChange{{Field: "two_factor_secret", Redacted: true}},
+		},
+		{
+			"recovery codes",
+			&base, with(func(u *models.User) { u.RecoveryCodeHashes = []string{"other"} }),
+			[]models.FieldChange{{Field: "recovery_codes", Redacted: true}},
+		},
+		{
+			"deleted user",
+			with(func(u *models.User) {
+				u.Name, u.Email, u.Password, u.TOTPSecret, u.RecoveryCodeHashes = "", "", "", "", nil
+			}), nil,
+			[]models.FieldChange{{Field: "type", Before: models.UserTypeViewer}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UserChanges(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UserChanges() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserChangesEncryptsPersonalData(t *testing.T) {
+	before := models.User{ID: 7001, Name: "Alice", PhoneNumber: "+15550100"}
+	after := models.User{ID: 7001, Name: "Alicia", PhoneNumber: "+15550100"}
+
+	changes := UserChanges(&before, &after)
+	if len(changes) != 1 || changes[0].Field != "name" {
+		t.Fatalf("UserChanges() = %+v, want a single name change", changes)
+	}
+	for _, value := range []any{changes[0].Before, changes[0].After} {
+		sealed, _ := value.(string)
+		if !strings.HasPrefix(sealed, subjectPrefix+"7001:") {
+			t.Errorf("value %v not sealed with the user's key", value)
+		}
+	}
+	if got, _ := decrypt(1, changes[0].After, false); got != "Alicia" {
+		t.Errorf("decrypted After = %v, want Alicia", got)
+	}
+
+	// New accounts have no before value and deleted ones no after value
+	created := UserChanges(nil, &after)
+	if created[0].Before != nil || created[0].After == nil {
+		t.Errorf("creation change = %+v, want only After", created[0])
+	}
+	deleted := UserChanges(&before, nil)
+	if deleted[0].Before == nil || deleted[0].After != nil {
+		t.Errorf("deletion change = %+v, want only Before", deleted[0])
+	}
+}
+
+func TestRedact(t *testing.T) {
+	changes := []models.FieldChange{
+		{Field: "name", Before: "Alice", After: "Alicia"},
+		{Field: "password", Redacted: true},
+	}
+	want := []models.FieldChange{
+		{Field: "name", Redacted: true},
+		{Field: "password", Redacted: true},
+	}
+	if got := Redact(changes); !reflect.DeepEqual(got, want) {
+		t.Errorf("Redact() = %+v, want %+v", got, want)
+	}
+	if changes[0].Before != "Alice" {
+		t.Error("Redact() modified its argument")
+	}
+}
+
+func TestSettingsChanges(t *testing.T) {
+	before := models.Settings{RequireAdminTwoFactor: true, UserDeletionMode: "delete"}
+	after := models.Settings{RequireAdminTwoFactor: false, UserDeletionMode: "delete"}
+
+	want := []models.FieldChange{{Field: "require_admin_two_factor", Before: true, After: false}}
+	if got := SettingsChanges(before, after); !reflect.DeepEqual(got, want) {
+		t.Errorf("SettingsChanges() = %+v, want %+v", got, want)
+	}
+	if got := SettingsChanges(before, before); got != nil {
+		t.Errorf("SettingsChanges() of equal settings = %+v, want nil", got)
+	}
+}
+
+func TestRoleChanges(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after []string
+		want          []models.FieldChange
+	}{
+		{"same set", []string{models.PermUsersRead, models.PermUsersUpdate}, []string{models.PermUsersRead, models.PermUsersUpdate}, nil},
+		{"reordered", []string{models.PermUsersUpdate, models.PermUsersRead}, []string{models.PermUsersRead, models.PermUsersUpdate}, nil},
+		{
+			"permission added",
+			[]string{models.PermUsersRead}, []string{models.PermUsersUpdate, models.PermUsersRead},
+			[]models.FieldChange{{Field: "operator.permissions", Before: []string{models.PermUsersRead}, After: []string{models.PermUsersRead, models.PermUsersUpdate}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RoleChanges("operator", tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("RoleChanges() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShred(t *testing.T) {
+	const userID = 7002
+	before := models.User{ID: userID, Name: "Bob", Email: "bob@example.com", Type: models.UserTypeViewer}
+	after := before
+	after.Name, after.Type = "Robert", models.UserTypeOperator
+
+	Record(models.AuditEntry{Action: models.AuditUserUpdated, TargetID: userID, Changes: UserChanges(&before, &after)})
+	// Entries written before per-user keys were sealed with the field keys
+	Record(models.AuditEntry{Action: models.AuditUserUpdated, TargetID: userID, Changes: []models.FieldChange{
+		{Field: "email", Before: fieldcrypt.Encrypt("old@example.com"), After: fieldcrypt.Encrypt("bob@example.com")},
+	}})
+
+	list := func() map[string]models.FieldChange {
+		changes := map[string]models.FieldChange{}
+		for _, entry := range List(models.AuditFilter{TargetID: userID}) {
+			for _, change := range entry.Changes {
+				changes[change.Field] = change
+			}
+		}
+		return changes
+	}
+
+	if got := list(); got["name"].After != "Robert" || got["email"].Before != "old@example.com" {
+		t.Fatalf("List() before Shred = %+v, want decrypted values", got)
+	}
+
+	Shred(userID)
+
+	tests := []struct {
+		field string
+		want  models.FieldChange
+	}{
+		{"name", models.FieldChange{Field: "name", Redacted: true}},
+		{"email", models.FieldChange{Field: "email", Redacted: true}},
+		{"type", models.FieldChange{Field: "type", Before: models.UserTypeViewer, After: models.UserTypeOperator}},
+	}
+	changes := list()
+	for _, tt := range tests {
+		if got := changes[tt.field]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s after Shred = %+v, want %+v", tt.field, got, tt.want)
+		}
+	}
+
+	// No new key is created for an erased user
+	if got := UserChanges(&after, &before); got[0].Field != "name" || !got[0].Redacted {
+		t.Errorf("UserChanges() after Shred = %+v, want the name redacted", got)
+	}
+}
@@ -0,0 +1,136 @@
+package audit
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hr-backend-system/fieldcrypt"
+	"hr-backend-system/storage"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// subjectPrefix marks personal values sealed with the key of the user they
+// belong to. The user ID and the sealed value follow it.
+const subjectPrefix = "subject:v1:"
+
+// errErased is returned for users whose key was shredded
+var errErased = errors.New("subject was erased")
+
+// Shred deletes the key of an erased user, so their personal data in
+// earlier audit entries can no longer be decrypted, wherever the log was
+// copied to. Those values are listed as redacted from then on.
+func Shred(userID int) {
+	if err := storage.SubjectKeys().ShredSubjectKey(userID); err != nil {
+		log.Printf("ERROR: failed to shred audit key of user %d: %v", userID, err)
+	}
+}
+
+// sealPersonal encrypts a personal value of a user with the user's own
+// key, creating the key on first use. It returns false when the value
+// cannot be sealed, e.g. because the user was erased; it must not be
+// recorded then.
+func sealPersonal(userID int, value string) (string, bool) {
+	if value == "" {
+		return "", true
+	}
+	aead, err := subjectCipher(userID, true)
+	if err != nil {
+		if !errors.Is(err, errErased) {
+			log.Printf("ERROR: failed to seal audit data of user %d: %v", userID, err)
+		}
+		return "", false
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		log.Printf("ERROR: failed to seal audit data of user %d: %v", userID, err)
+		return "", false
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(strconv.Itoa(userID)))
+	return subjectPrefix + strconv.Itoa(userID) + ":" + base64.RawStdEncoding.EncodeToString(sealed), true
+}
+
+// openPersonal decrypts a value sealed by sealPersonal. It returns
+// errErased when the user's key was shredded.
+func openPersonal(value string) (string, error) {
+	id, encoded, ok := strings.Cut(strings.TrimPrefix(value, subjectPrefix), ":")
+	userID, err := strconv.Atoi(id)
+	if !ok || err != nil {
+		return "", fieldcrypt.ErrMalformed
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fieldcrypt.ErrMalformed
+	}
+
+	aead, err := subjectCipher(userID, false)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", fieldcrypt.ErrMalformed
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(id))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// subjectErased reports whether the key of a user was shredded
+func subjectErased(userID int) bool {
+	if userID == 0 {
+		return false
+	}
+	_, erased := storage.SubjectKeys().GetSubjectKey(userID)
+	return erased
+}
+
+// subjectCipher returns the cipher of a user's key. The key itself is
+// stored sealed with the field encryption keys. Ciphers are not cached, a
+// shredded key must stop working at once.
+func subjectCipher(userID int, create bool) (cipher.AEAD, error) {
+	keys := storage.SubjectKeys()
+	sealed, erased := keys.GetSubjectKey(userID)
+	if erased {
+		return nil, errErased
+	}
+	if sealed == "" {
+		if !create {
+			return nil, fieldcrypt.ErrKeyUnknown
+		}
+		raw := make([]byte, 32)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, fmt.Errorf("failed to generate key: %w", err)
+		}
+		// Another request may have added a key first, use whichever won
+		stored, err := keys.AddSubjectKey(userID, fieldcrypt.Encrypt(base64.RawStdEncoding.EncodeToString(raw)))
+		if err != nil {
+			return nil, err
+		}
+		if stored == "" {
+			return nil, errErased
+		}
+		sealed = stored
+	}
+
+	encoded, err := fieldcrypt.Decrypt(sealed)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := base64.RawStdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fieldcrypt.ErrMalformed
+	}
+	block, err := aes.NewCipher(raw)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -1,7 +1,17 @@
 package main
 
 import (
+	"hr-backend-system/audit"
+	"hr-backend-system/config"
+	"hr-backend-system/dataexport"
+	"hr-backend-system/fieldcrypt"
+	"hr-backend-system/models"
 	"hr-backend-system/routes"
+	"hr-backend-system/storage"
+	"hr-backend-system/utils"
+	"log"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 
@@ -12,7 +22,21 @@ import (
 	swaggerFiles "github.com/swaggo/files"
 )
 
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Type "Bearer" followed by a space and the access token.
+//
+// @securityDefinitions.apikey APIKeyAuth
+// @in header
+// @name X-API-Key
+// @description Service account API key.
 func main() {
+	loadEncryptionKeys()
+	openAuditLog()
+	bootstrapOwner()
+	dataexport.StartSweeper()
+
 	router := gin.Default()
 
 	// Add Swagger route
@@ -23,3 +47,69 @@ func main() {
 
 	router.Run(":8080")
 }
+
+// loadEncryptionKeys sets up field encryption with the local key file.
+// A key management service can be used instead by passing its
+// fieldcrypt.KeyProvider to fieldcrypt.Configure.
+func loadEncryptionKeys() {
+	keys, err := fieldcrypt.LoadKeyFile(config.Get().EncryptionKeyFile)
+	if err != nil {
+		log.Fatalf("failed to load encryption keys: %v", err)
+	}
+	if err := fieldcrypt.Configure(keys, keys.BlindIndexKey()); err != nil {
+		log.Fatalf("failed to configure encryption: %v", err)
+	}
+}
+
+// openAuditLog switches the audit log and the keys of its personal data
+// to the configured files so entries survive restarts
+func openAuditLog() {
+	store, err := storage.NewFileAuditStore(config.Get().AuditLogFile)
+	if err != nil {
+		log.Fatalf("failed to open audit log: %v", err)
+	}
+	storage.SetAuditStore(store)
+
+	keys, err := storage.NewFileSubjectKeyStore(config.Get().AuditKeyFile)
+	if err != nil {
+		log.Fatalf("failed to load audit keys: %v", err)
+	}
+	storage.SetSubjectKeyStore(keys)
+}
+
+// bootstrapOwner creates the initial owner account from config so the
+// protected user endpoints can be reached on a fresh store
+func bootstrapOwner() {
+	cfg := config.Get()
+	if cfg.BootstrapOwnerEmail == "" || cfg.BootstrapOwnerPassword == "" {
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(cfg.BootstrapOwnerEmail))
+	if _, exists := storage.GetUserByEmail(email); exists {
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(cfg.BootstrapOwnerPassword)
+	if err != nil {
+		log.Fatalf("failed to hash bootstrap owner password: %v", err)
+	}
+
+	owner := models.User{
+		ID:            storage.GetNextUserID(),
+		Name:          cfg.BootstrapOwnerName,
+		Email:         email,
+		EmailVerified: true,
+		Type:          models.UserTypeOwner,
+		Password:      hashedPassword,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	storage.AddUser(owner)
+	audit.Record(models.AuditEntry{
+		Action:   models.AuditUserCreated,
+		TargetID: owner.ID,
+		Changes:  audit.UserChanges(nil, &owner),
+	})
+	log.Printf("Created bootstrap owner account %s", email)
+}
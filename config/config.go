@@ -1 +1,556 @@
 package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config holds application settings loaded from environment variables
+type Config struct {
+	// Deployment environment: development, staging or production. It
+	// selects the defaults of the security settings.
+	Environment string
+
+	JWTSecret       string
+	JWTIssuer       string
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+
+	// Number of previous passwords a user may not reuse
+	PasswordHistorySize int
+	PasswordResetTTL    time.Duration
+
+	// Password hashing, PasswordHashAlgorithm is "argon2id" or "bcrypt".
+	// Hashes made with other settings are upgraded at the next login.
+	PasswordHashAlgorithm string
+	Argon2Memory          int // KiB
+	Argon2Iterations      int
+	Argon2Parallelism     int
+	BcryptCost            int
+
+	// Password policy, see the passwordpolicy package. BreachedPasswordDir
+	// holds SHA-1 hash prefix files, the check is off when it is empty.
+	PasswordMinLength          int
+	PasswordRequireUpper       bool
+	PasswordRequireLower       bool
+	PasswordRequireDigit       bool
+	PasswordRequireSymbol      bool
+	PasswordRejectPersonalInfo bool
+	BreachedPasswordDir        string
+	BreachedPasswordMinCount   int
+
+	// Email verification, the requirement can be changed at runtime by admins
+	EmailVerificationTTL     time.Duration
+	RequireEmailVerification bool
+
+	// What DeleteUser does, "delete" or "anonymize", can be changed at runtime
+	UserDeletionMode string
+
+	// Two-factor authentication, the admin requirement can be changed at runtime
+	TOTPIssuer            string
+	RequireAdminTwoFactor bool
+	TwoFactorChallengeTTL time.Duration
+
+	// Brute-force protection for credential checks
+	LoginMaxFailures     int
+	LoginIPMaxFailures   int
+	LoginFailureWindow   time.Duration
+	LoginLockoutDuration time.Duration
+	LoginDelayAfter      int
+	LoginDelayBase       time.Duration
+
+	// Request quotas per route group, see loadRateLimits
+	RateLimitEnabled bool
+	RateLimits       map[string]RateLimit
+
+	// JSON lines file the audit log is appended to, and the file holding
+	// the per-user keys its personal data is encrypted with
+	AuditLogFile string
+	AuditKeyFile string
+
+	// File holding the keys personal data is encrypted with, created on
+	// first start. Losing it makes the encrypted data unreadable.
+	EncryptionKeyFile string
+
+	// Personal data exports are written to DataExportDir and can be
+	// downloaded until DataExportTTL has passed
+	DataExportDir string
+	DataExportTTL time.Duration
+
+	// Lifetime of impersonation tokens, they cannot be refreshed
+	ImpersonationTTL time.Duration
+
+	// Time a recipient has to accept an ownership transfer
+	OwnershipTransferTTL time.Duration
+
+	// Base URL of the frontend, used for links in emails
+	AppBaseURL string
+
+	// Public base URL of this API, used for OAuth redirect URLs
+	APIBaseURL string
+
+	// Cross-origin policy, CORSGroups override it for route groups
+	CORS       CORSPolicy
+	CORSGroups []CORSPolicy
+
+	// Security headers, HSTS is only sent when HSTSMaxAge is positive
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	FrameAncestors        string
+	ReferrerPolicy        string
+
+	// Double-submit CSRF protection for requests carrying the auth cookie,
+	// e.g. set by a frontend proxy. SecureCookies restricts cookies set by
+	// the API to HTTPS.
+	CSRFEnabled    bool
+	CSRFCookieName string
+	CSRFHeaderName string
+	AuthCookieName string
+	SecureCookies  bool
+
+	// OpenID Connect login providers, see loadOIDCProviders
+	OIDCProviders []OIDCProvider
+	OIDCStateTTL  time.Duration
+
+	// Mail delivery, MailDriver is "smtp" or "file"
+	MailDriver    string
+	MailFrom      string
+	MailOutboxDir string
+	SMTPHost      string
+	SMTPPort      int
+	SMTPUsername  string
+	SMTPPassword  string
+
+	// Initial owner account, created at startup when set
+	BootstrapOwnerName     string
+	BootstrapOwnerEmail    string
+	BootstrapOwnerPassword string
+}
+
+var (
+	cfg  *Config
+	once sync.Once
+)
+
+// Get returns the application config, loading it on first use
+func Get() *Config {
+	once.Do(func() {
+		cfg = load()
+	})
+	return cfg
+}
+
+func load() *Config {
+	environment := strings.ToLower(getEnv("APP_ENV", "development"))
+	apiBaseURL := strings.TrimSuffix(getEnv("API_BASE_URL", "http://localhost:8080"), "/")
+
+	// Deployed environments are served over HTTPS, development usually is not
+	deployed := environment != "development"
+	defaultHSTSMaxAge := time.Duration(0)
+	if deployed {
+		defaultHSTSMaxAge = 365 * 24 * time.Hour
+	}
+
+	c := &Config{
+		Environment: environment,
+
+		JWTSecret:       getEnv("JWT_SECRET", ""),
+		JWTIssuer:       getEnv("JWT_ISSUER", "hr-backend-system"),
+		AccessTokenTTL:  getDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL: getDuration("REFRESH_TOKEN_TTL", 7*24*time.Hour),
+
+		PasswordHistorySize: getInt("PASSWORD_HISTORY_SIZE", 5),
+		PasswordResetTTL:    getDuration("PASSWORD_RESET_TTL", time.Hour),
+
+		PasswordHashAlgorithm: getEnv("PASSWORD_HASH_ALGORITHM", "argon2id"),
+		Argon2Memory:          getInt("ARGON2_MEMORY", 19*1024),
+		Argon2Iterations:      getInt("ARGON2_ITERATIONS", 2),
+		Argon2Parallelism:     getInt("ARGON2_PARALLELISM", 1),
+		BcryptCost:            getInt("BCRYPT_COST", 10),
+
+		PasswordMinLength:          getInt("PASSWORD_MIN_LENGTH", 8),
+		PasswordRequireUpper:       getBool("PASSWORD_REQUIRE_UPPER", false),
+		PasswordRequireLower:       getBool("PASSWORD_REQUIRE_LOWER", false),
+		PasswordRequireDigit:       getBool("PASSWORD_REQUIRE_DIGIT", false),
+		PasswordRequireSymbol:      getBool("PASSWORD_REQUIRE_SYMBOL", false),
+		PasswordRejectPersonalInfo: getBool("PASSWORD_REJECT_PERSONAL_INFO", true),
+		BreachedPasswordDir:        getEnv("BREACHED_PASSWORD_DIR", ""),
+		BreachedPasswordMinCount:   getInt("BREACHED_PASSWORD_MIN_COUNT", 1),
+
+		EmailVerificationTTL:     getDuration("EMAIL_VERIFICATION_TTL", 48*time.Hour),
+		RequireEmailVerification: getBool("REQUIRE_EMAIL_VERIFICATION", false),
+
+		UserDeletionMode: getEnv("USER_DELETION_MODE", "delete"),
+
+		TOTPIssuer:            getEnv("TOTP_ISSUER", "HR Backend System"),
+		RequireAdminTwoFactor: getBool("REQUIRE_ADMIN_TWO_FACTOR", true),
+		TwoFactorChallengeTTL: getDuration("TWO_FACTOR_CHALLENGE_TTL", 5*time.Minute),
+
+		LoginMaxFailures:     getInt("LOGIN_MAX_FAILURES", 5),
+		LoginIPMaxFailures:   getInt("LOGIN_IP_MAX_FAILURES", 50),
+		LoginFailureWindow:   getDuration("LOGIN_FAILURE_WINDOW", 15*time.Minute),
+		LoginLockoutDuration: getDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		LoginDelayAfter:      getInt("LOGIN_DELAY_AFTER", 2),
+		LoginDelayBase:       getDuration("LOGIN_DELAY_BASE", time.Second),
+
+		RateLimitEnabled: getBool("RATE_LIMIT_ENABLED", true),
+
+		AuditLogFile: getEnv("AUDIT_LOG_FILE", "audit.jsonl"),
+		AuditKeyFile: getEnv("AUDIT_KEY_FILE", "audit_keys.json"),
+
+		EncryptionKeyFile: getEnv("ENCRYPTION_KEY_FILE", "encryption_keys.json"),
+
+		DataExportDir: getEnv("DATA_EXPORT_DIR", "exports"),
+		DataExportTTL: getDuration("DATA_EXPORT_TTL", 24*time.Hour),
+
+		ImpersonationTTL: getDuration("IMPERSONATION_TTL", 30*time.Minute),
+
+		OwnershipTransferTTL: getDuration("OWNERSHIP_TRANSFER_TTL", 72*time.Hour),
+
+		AppBaseURL: getEnv("APP_BASE_URL", "http://localhost:3000"),
+		APIBaseURL: apiBaseURL,
+
+		HSTSMaxAge:            getDuration("HSTS_MAX_AGE", defaultHSTSMaxAge),
+		HSTSIncludeSubdomains: getBool("HSTS_INCLUDE_SUBDOMAINS", deployed),
+		FrameAncestors:        getEnv("CSP_FRAME_ANCESTORS", "'none'"),
+		ReferrerPolicy:        getEnv("REFERRER_POLICY", "strict-origin-when-cross-origin"),
+
+		CSRFEnabled:    getBool("CSRF_ENABLED", true),
+		CSRFCookieName: getEnv("CSRF_COOKIE_NAME", "csrf_token"),
+		CSRFHeaderName: getEnv("CSRF_HEADER_NAME", "X-CSRF-Token"),
+		AuthCookieName: getEnv("AUTH_COOKIE_NAME", "access_token"),
+		SecureCookies:  getBool("SECURE_COOKIES", deployed || strings.HasPrefix(apiBaseURL, "https://")),
+
+		OIDCStateTTL: getDuration("OIDC_STATE_TTL", 10*time.Minute),
+
+		MailDriver:    getEnv("MAIL_DRIVER", "file"),
+		MailFrom:      getEnv("MAIL_FROM", "no-reply@hr-backend-system.local"),
+		MailOutboxDir: getEnv("MAIL_OUTBOX_DIR", "outbox"),
+		SMTPHost:      getEnv("SMTP_HOST", "localhost"),
+		SMTPPort:      getInt("SMTP_PORT", 587),
+		SMTPUsername:  getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:  getEnv("SMTP_PASSWORD", ""),
+
+		BootstrapOwnerName:     getEnv("BOOTSTRAP_OWNER_NAME", "Owner"),
+		BootstrapOwnerEmail:    getEnv("BOOTSTRAP_OWNER_EMAIL", ""),
+		BootstrapOwnerPassword: getEnv("BOOTSTRAP_OWNER_PASSWORD", ""),
+	}
+
+	c.OIDCProviders = loadOIDCProviders(c.APIBaseURL)
+	c.RateLimits = loadRateLimits()
+	c.CORS = loadCORSPolicy("CORS_", defaultCORSPolicy)
+	c.CORSGroups = loadCORSGroups(c.CORS)
+
+	if c.PasswordHashAlgorithm != "argon2id" && c.PasswordHashAlgorithm != "bcrypt" {
+		log.Printf("WARNING: unknown PASSWORD_HASH_ALGORITHM %q, using argon2id", c.PasswordHashAlgorithm)
+		c.PasswordHashAlgorithm = "argon2id"
+	}
+
+	// A negative history size would make trimming the history panic, so
+	// refuse to start instead
+	requireRange("PASSWORD_HISTORY_SIZE", c.PasswordHistorySize, 0, 100)
+	// Out of range values would make hashing panic on the first login
+	requireRange("ARGON2_MEMORY", c.Argon2Memory, 8, 4*1024*1024)
+	requireRange("ARGON2_ITERATIONS", c.Argon2Iterations, 1, 100)
+	requireRange("ARGON2_PARALLELISM", c.Argon2Parallelism, 1, 255)
+	requireRange("BCRYPT_COST", c.BcryptCost, 4, 31)
+
+	if c.UserDeletionMode != "delete" && c.UserDeletionMode != "anonymize" {
+		log.Printf("WARNING: unknown USER_DELETION_MODE %q, using delete", c.UserDeletionMode)
+		c.UserDeletionMode = "delete"
+	}
+
+	// Fall back to a random secret so development still works,
+	// but tokens will not survive a restart
+	if c.JWTSecret == "" {
+		log.Println("WARNING: JWT_SECRET is not set, using a random secret")
+		c.JWTSecret = randomSecret()
+	}
+
+	return c
+}
+
+// OIDCProvider describes an OpenID Connect identity provider users can log in with
+type OIDCProvider struct {
+	Name         string
+	DisplayName  string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// loadOIDCProviders reads the providers named in OIDC_PROVIDERS, e.g.
+// "google,mock". Each one is configured with OIDC_<NAME>_* variables.
+// Providers without a client ID or issuer URL are skipped.
+func loadOIDCProviders(apiBaseURL string) []OIDCProvider {
+	var providers []OIDCProvider
+	for _, name := range strings.Split(getEnv("OIDC_PROVIDERS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		defaultIssuer, defaultDisplayName := "", name
+		if name == "google" {
+			defaultIssuer, defaultDisplayName = "https://accounts.google.com", "Google"
+		}
+
+		p := OIDCProvider{
+			Name:         name,
+			DisplayName:  getEnv(prefix+"DISPLAY_NAME", defaultDisplayName),
+			IssuerURL:    getEnv(prefix+"ISSUER_URL", defaultIssuer),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  getEnv(prefix+"REDIRECT_URL", apiBaseURL+"/api/v1/auth/oidc/"+name+"/callback"),
+			Scopes:       strings.Fields(strings.ReplaceAll(getEnv(prefix+"SCOPES", "openid email profile"), ",", " ")),
+		}
+		if p.IssuerURL == "" || p.ClientID == "" {
+			log.Printf("WARNING: OIDC provider %s needs %sISSUER_URL and %sCLIENT_ID, skipping", name, prefix, prefix)
+			continue
+		}
+		providers = append(providers, p)
+	}
+	return providers
+}
+
+// RateLimit allows Requests per Period for each caller, with bursts of up
+// to Requests. A zero RateLimit means no limit.
+type RateLimit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// RateLimitFor returns the quota of a route group, falling back to the
+// "default" group
+func (c *Config) RateLimitFor(group string) RateLimit {
+	if limit, ok := c.RateLimits[group]; ok {
+		return limit
+	}
+	return c.RateLimits["default"]
+}
+
+// defaultRateLimits apply unless overridden by RATE_LIMIT_<GROUP>
+var defaultRateLimits = map[string]RateLimit{
+	"default": {Requests: 300, Period: time.Minute},
+	"auth":    {Requests: 20, Period: time.Minute},
+	// Per client IP in front of authentication, see middleware.RateLimitIP
+	"ip": {Requests: 600, Period: time.Minute},
+}
+
+// loadRateLimits reads RATE_LIMIT_<GROUP> variables such as
+// RATE_LIMIT_USERS=100/1m, where GROUP is a route group name. A value of
+// "off" or "0" removes the limit for the group.
+func loadRateLimits() map[string]RateLimit {
+	limits := make(map[string]RateLimit, len(defaultRateLimits))
+	for group, limit := range defaultRateLimits {
+		limits[group] = limit
+	}
+
+	for _, env := range os.Environ() {
+		key, value, _ := strings.Cut(env, "=")
+		name, ok := strings.CutPrefix(key, "RATE_LIMIT_")
+		if !ok || name == "ENABLED" || value == "" {
+			continue
+		}
+		limit, err := parseRateLimit(value)
+		if err != nil {
+			log.Printf("WARNING: invalid rate limit for %s: %q, expected e.g. 100/1m", key, value)
+			continue
+		}
+		limits[strings.ToLower(name)] = limit
+	}
+	return limits
+}
+
+func parseRateLimit(value string) (RateLimit, error) {
+	value = strings.TrimSpace(value)
+	if value == "off" || value == "0" {
+		return RateLimit{}, nil
+	}
+	requests, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("missing period")
+	}
+	n, err := strconv.Atoi(requests)
+	if err != nil || n < 1 {
+		return RateLimit{}, fmt.Errorf("invalid request count")
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return RateLimit{}, fmt.Errorf("invalid period")
+	}
+	return RateLimit{Requests: n, Period: d}, nil
+}
+
+// CORSPolicy controls which browser origins may call the API
+type CORSPolicy struct {
+	PathPrefix       string   // Route group the policy applies to, empty for the default policy
+	AllowOrigins     []string // e.g. https://app.example.com or https://*.example.com, "*" allows any origin
+	AllowMethods     []string
+	AllowHeaders     []string
+	ExposeHeaders    []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// defaultCORSPolicy allows the local frontend during development
+var defaultCORSPolicy = CORSPolicy{
+	AllowOrigins:     []string{"http://localhost:3000", "http://localhost:8080"},
+	AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+	AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-CSRF-Token", "X-Request-ID"},
+	ExposeHeaders:    []string{"Content-Length", "X-Request-ID", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After"},
+	AllowCredentials: true,
+	MaxAge:           12 * time.Hour,
+}
+
+// loadCORSPolicy reads <prefix>ALLOW_ORIGINS, ALLOW_METHODS, ALLOW_HEADERS,
+// EXPOSE_HEADERS, ALLOW_CREDENTIALS and MAX_AGE. Unset values are taken
+// from base.
+func loadCORSPolicy(prefix string, base CORSPolicy) CORSPolicy {
+	p := CORSPolicy{
+		AllowOrigins:     getList(prefix+"ALLOW_ORIGINS", base.AllowOrigins),
+		AllowMethods:     getList(prefix+"ALLOW_METHODS", base.AllowMethods),
+		AllowHeaders:     getList(prefix+"ALLOW_HEADERS", base.AllowHeaders),
+		ExposeHeaders:    getList(prefix+"EXPOSE_HEADERS", base.ExposeHeaders),
+		AllowCredentials: getBool(prefix+"ALLOW_CREDENTIALS", base.AllowCredentials),
+		MaxAge:           getDuration(prefix+"MAX_AGE", base.MaxAge),
+	}
+
+	origins := p.AllowOrigins[:0:0]
+	for _, origin := range p.AllowOrigins {
+		if !validCORSOrigin(origin) {
+			log.Printf("WARNING: invalid origin in %sALLOW_ORIGINS: %q, expected e.g. https://app.example.com or https://*.example.com", prefix, origin)
+			continue
+		}
+		origins = append(origins, strings.TrimSuffix(origin, "/"))
+	}
+	p.AllowOrigins = origins
+
+	// Browsers refuse credentials for a wildcard origin
+	if slices.Contains(p.AllowOrigins, "*") && p.AllowCredentials {
+		log.Printf("WARNING: %sALLOW_ORIGINS allows any origin, disabling credentials", prefix)
+		p.AllowCredentials = false
+	}
+	return p
+}
+
+// loadCORSGroups reads the route groups named in CORS_GROUPS, e.g.
+// "careers". Each one is configured with CORS_<NAME>_* variables on top of
+// the default policy and applies below CORS_<NAME>_PATH_PREFIX, by default
+// /api/v1/<name>.
+func loadCORSGroups(base CORSPolicy) []CORSPolicy {
+	var groups []CORSPolicy
+	for _, name := range strings.Split(getEnv("CORS_GROUPS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "CORS_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		p := loadCORSPolicy(prefix, base)
+		p.PathPrefix = strings.TrimSuffix(getEnv(prefix+"PATH_PREFIX", "/api/v1/"+name), "/")
+		groups = append(groups, p)
+	}
+	return groups
+}
+
+// validCORSOrigin accepts "*", scheme://host[:port] and patterns with a
+// "*." subdomain wildcard in front of the host
+func validCORSOrigin(origin string) bool {
+	if origin == "*" {
+		return true
+	}
+	scheme, host, ok := strings.Cut(strings.TrimSuffix(origin, "/"), "://")
+	if !ok || (scheme != "http" && scheme != "https") {
+		return false
+	}
+	host = strings.TrimPrefix(host, "*.")
+	return host != "" && !strings.ContainsAny(host, "*/?#@ ")
+}
+
+func getList(key string, fallback []string) []string {
+	value, ok := os.LookupEnv(key)
+	if !ok || strings.TrimSpace(value) == "" {
+		return fallback
+	}
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getBool(key string, fallback bool) bool {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("WARNING: invalid boolean for %s: %q, using %t", key, value, fallback)
+		return fallback
+	}
+	return b
+}
+
+func getInt(key string, fallback int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("WARNING: invalid integer for %s: %q, using %d", key, value, fallback)
+		return fallback
+	}
+	return n
+}
+
+func getDuration(key string, fallback time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("WARNING: invalid duration for %s: %q, using %s", key, value, fallback)
+		return fallback
+	}
+	return d
+}
+
+// requireRange stops the process when the value of an environment
+// variable is outside [lo, hi]
+func requireRange(key string, value, lo, hi int) {
+	if value < lo || value > hi {
+		log.Fatalf("invalid %s %d, must be between %d and %d", key, value, lo, hi)
+	}
+}
+
+func randomSecret() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		log.Fatalf("failed to generate JWT secret: %v", err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLoadCORSPolicy(t *testing.T) {
+	tests := []struct {
+		name            string
+		origins         string
+		wantOrigins     []string
+		wantCredentials bool
+	}{
+		{"default", "", defaultCORSPolicy.AllowOrigins, true},
+		{"list", "https://app.example.com/, https://*.example.org", []string{"https://app.example.com", "https://*.example.org"}, true},
+		{"invalid origins dropped", "ftp://files.example.com,https://*,app.example.com,https://ok.example.com", []string{"https://ok.example.com"}, true},
+		{"any origin", "*", []string{"*"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEST_ALLOW_ORIGINS", tt.origins)
+			p := loadCORSPolicy("TEST_", defaultCORSPolicy)
+			if !reflect.DeepEqual(p.AllowOrigins, tt.wantOrigins) {
+				t.Errorf("AllowOrigins = %q, want %q", p.AllowOrigins, tt.wantOrigins)
+			}
+			if p.AllowCredentials != tt.wantCredentials {
+				t.Errorf("AllowCredentials = %v, want %v", p.AllowCredentials, tt.wantCredentials)
+			}
+		})
+	}
+}
+
+func TestLoadCORSGroups(t *testing.T) {
+	t.Setenv("CORS_GROUPS", "careers, partner-api")
+	t.Setenv("CORS_CAREERS_ALLOW_ORIGINS", "*")
+	t.Setenv("CORS_PARTNER_API_PATH_PREFIX", "/api/v1/partners/")
+
+	groups := loadCORSGroups(defaultCORSPolicy)
+	if len(groups) != 2 {
+		t.Fatalf("%d groups, want 2", len(groups))
+	}
+	if groups[0].PathPrefix != "/api/v1/careers" || !reflect.DeepEqual(groups[0].AllowOrigins, []string{"*"}) || groups[0].AllowCredentials {
+		t.Errorf("careers = %+v", groups[0])
+	}
+	if groups[1].PathPrefix != "/api/v1/partners" || !reflect.DeepEqual(groups[1].AllowOrigins, defaultCORSPolicy.AllowOrigins) {
+		t.Errorf("partner-api = %+v", groups[1])
+	}
+}
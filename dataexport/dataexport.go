@@ -0,0 +1,274 @@
+package dataexport
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"hr-backend-system/audit"
+	"hr-backend-system/config"
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"hr-backend-system/utils"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Start records a pending export of user and generates it in the
+// background. Expired exports and their files are removed first.
+func Start(user models.User, requestedBy int, format string) (models.DataExport, error) {
+	removeExpired()
+
+	id, err := utils.GenerateID()
+	if err != nil {
+		return models.DataExport{}, err
+	}
+
+	now := time.Now()
+	export := models.DataExport{
+		ID:          id,
+		UserID:      user.ID,
+		RequestedBy: requestedBy,
+		Format:      format,
+		Status:      models.DataExportPending,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(config.Get().DataExportTTL),
+	}
+	storage.AddDataExport(export)
+
+	go generate(export, user.ID)
+	return export, nil
+}
+
+// generate writes the export file and marks the export ready or failed
+func generate(export models.DataExport, userID int) {
+	err := func() error {
+		user, exists := storage.GetUserByID(userID)
+		if !exists {
+			return fmt.Errorf("user %d no longer exists", userID)
+		}
+
+		dir := config.Get().DataExportDir
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return fmt.Errorf("create export directory: %w", err)
+		}
+
+		path := filepath.Join(dir, export.ID+"."+export.Format)
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return fmt.Errorf("create export file: %w", err)
+		}
+		export.FilePath = path
+
+		if err := Write(file, Collect(user), export.Format); err != nil {
+			file.Close()
+			return err
+		}
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return fmt.Errorf("stat export file: %w", err)
+		}
+		export.Size = info.Size()
+		return file.Close()
+	}()
+
+	now := time.Now()
+	export.CompletedAt = &now
+	export.Status = models.DataExportReady
+	if err != nil {
+		log.Printf("ERROR: data export %s for user %d failed: %v", export.ID, userID, err)
+		if export.FilePath != "" {
+			os.Remove(export.FilePath)
+			export.FilePath = ""
+		}
+		export.Status = models.DataExportFailed
+		export.Error = "failed to generate export"
+	}
+
+	// The user may have been deleted or anonymized while the file was
+	// written, which removed the export. Nothing points to the file then.
+	if !storage.UpdateDataExport(export) {
+		removeFiles([]models.DataExport{export})
+	}
+}
+
+// Collect gathers everything stored about user. Secrets such as password
+// hashes are left out by the models' JSON encoding.
+func Collect(user models.User) models.DataExportBundle {
+	bundle := models.DataExportBundle{
+		GeneratedAt:        time.Now(),
+		User:               user.ToResponse(),
+		Sessions:           storage.GetAllUserSessions(user.ID),
+		OIDCIdentities:     storage.GetUserOIDCIdentities(user.ID),
+		APIKeys:            storage.GetAPIKeysByUser(user.ID),
+		OwnershipTransfers: storage.GetUserOwnershipTransfers(user.ID),
+		RoleChangeRequests: []models.RoleChangeRequest{},
+		SecurityEvents:     []models.SecurityEvent{},
+	}
+	if bundle.APIKeys == nil {
+		bundle.APIKeys = []models.APIKey{}
+	}
+	if bundle.OwnershipTransfers == nil {
+		bundle.OwnershipTransfers = []models.OwnershipTransfer{}
+	}
+
+	for _, request := range storage.GetRoleChangeRequests("") {
+		if request.UserID == user.ID {
+			bundle.RoleChangeRequests = append(bundle.RoleChangeRequests, request)
+		}
+	}
+
+	for _, event := range storage.SecurityEvents().ListSecurityEvents("", math.MaxInt) {
+		if event.UserID == user.ID || (event.Email != "" && strings.EqualFold(event.Email, user.Email)) {
+			bundle.SecurityEvents = append(bundle.SecurityEvents, event)
+		}
+	}
+
+	// Changes to the account and changes the user made to others. The
+	// values of the latter are other people's data, so only the changed
+	// fields are included.
+	seen := map[int]bool{}
+	for _, filter := range []models.AuditFilter{{TargetID: user.ID}, {ActorID: user.ID}} {
+		for _, entry := range audit.List(filter) {
+			if seen[entry.ID] {
+				continue
+			}
+			seen[entry.ID] = true
+			if entry.TargetID != user.ID {
+				entry.Changes = audit.Redact(entry.Changes)
+			}
+			bundle.AuditEntries = append(bundle.AuditEntries, entry)
+		}
+	}
+	sort.Slice(bundle.AuditEntries, func(i, j int) bool {
+		return bundle.AuditEntries[i].ID > bundle.AuditEntries[j].ID
+	})
+	if bundle.AuditEntries == nil {
+		bundle.AuditEntries = []models.AuditEntry{}
+	}
+
+	return bundle
+}
+
+// Write encodes bundle as one JSON document, or as a ZIP archive with one
+// JSON file per section
+func Write(w io.Writer, bundle models.DataExportBundle, format string) error {
+	if format == models.DataExportFormatJSON {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(bundle); err != nil {
+			return fmt.Errorf("write export: %w", err)
+		}
+		return nil
+	}
+
+	archive := zip.NewWriter(w)
+	sections := []struct {
+		name string
+		data any
+	}{
+		{"user.json", bundle.User},
+		{"sessions.json", bundle.Sessions},
+		{"oidc_identities.json", bundle.OIDCIdentities},
+		{"api_keys.json", bundle.APIKeys},
+		{"ownership_transfers.json", bundle.OwnershipTransfers},
+		{"role_change_requests.json", bundle.RoleChangeRequests},
+		{"security_events.json", bundle.SecurityEvents},
+		{"audit_entries.json", bundle.AuditEntries},
+		{"manifest.json", map[string]any{"generated_at": bundle.GeneratedAt, "user_id": bundle.User.ID}},
+	}
+	for _, section := range sections {
+		file, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     section.name,
+			Method:   zip.Deflate,
+			Modified: bundle.GeneratedAt,
+		})
+		if err != nil {
+			return fmt.Errorf("write export: %w", err)
+		}
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(section.data); err != nil {
+			return fmt.Errorf("write export: %w", err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("write export: %w", err)
+	}
+	return nil
+}
+
+// RemoveUserExports deletes every export of a user and its file, e.g.
+// when the user's personal data is erased
+func RemoveUserExports(userID int) {
+	removeFiles(storage.RemoveUserDataExports(userID))
+}
+
+// sweepInterval is how often StartSweeper removes expired exports
+const sweepInterval = 5 * time.Minute
+
+// StartSweeper removes expired exports and their files in the background,
+// so the files do not outlive their TTL when no new export is started.
+// Files left behind by an earlier run are removed once they are as old as
+// the TTL.
+func StartSweeper() {
+	go func() {
+		ticker := time.NewTicker(sweepInterval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			removeExpired()
+			removeOrphans(now)
+		}
+	}()
+}
+
+// removeExpired deletes expired exports and their files
+func removeExpired() {
+	removeFiles(storage.RemoveExpiredDataExports(time.Now()))
+}
+
+// removeOrphans deletes export files older than the TTL that no stored
+// export points to, e.g. written before a restart of the in-memory store
+func removeOrphans(now time.Time) {
+	cfg := config.Get()
+	entries, err := os.ReadDir(cfg.DataExportDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("WARNING: failed to list data exports: %v", err)
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		id, format, _ := strings.Cut(entry.Name(), ".")
+		if entry.IsDir() || (format != models.DataExportFormatJSON && format != models.DataExportFormatZIP) {
+			continue
+		}
+		if _, exists := storage.GetDataExport(id); exists {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || now.Sub(info.ModTime()) < cfg.DataExportTTL {
+			continue
+		}
+		if err := os.Remove(filepath.Join(cfg.DataExportDir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			log.Printf("WARNING: failed to remove data export file %s: %v", entry.Name(), err)
+		}
+	}
+}
+
+func removeFiles(exports []models.DataExport) {
+	for _, export := range exports {
+		if export.FilePath != "" {
+			if err := os.Remove(export.FilePath); err != nil && !os.IsNotExist(err) {
+				log.Printf("WARNING: failed to remove data export %s: %v", export.ID, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package dataexport
+
+import (
+	"hr-backend-system/config"
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRemoveOrphans(t *testing.T) {
+	cfg := config.Get()
+	dir, ttl := cfg.DataExportDir, cfg.DataExportTTL
+	cfg.DataExportDir, cfg.DataExportTTL = t.TempDir(), time.Hour
+	defer func() { cfg.DataExportDir, cfg.DataExportTTL = dir, ttl }()
+
+	now := time.Now()
+	storage.AddDataExport(models.DataExport{ID: "stored", Status: models.DataExportReady, ExpiresAt: now.Add(time.Hour)})
+	defer storage.RemoveExpiredDataExports(now.Add(2 * time.Hour))
+
+	files := map[string]bool{
+		"orphan.json": false, // older than the TTL without an export
+		"orphan.zip":  false,
+		"stored.json": true, // still listed
+		"recent.json": true, // may be an export being written
+		"notes.txt":   true, // not an export file
+	}
+	for name := range files {
+		path := filepath.Join(cfg.DataExportDir, name)
+		if err := os.WriteFile(path, []byte("{}"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if name != "recent.json" {
+			old := now.Add(-2 * time.Hour)
+			os.Chtimes(path, old, old)
+		}
+	}
+
+	removeOrphans(now)
+	for name, kept := range files {
+		_, err := os.Stat(filepath.Join(cfg.DataExportDir, name))
+		if exists := err == nil; exists != kept {
+			t.Errorf("%s exists = %v, want %v", name, exists, kept)
+		}
+	}
+}
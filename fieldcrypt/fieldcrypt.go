@@ -0,0 +1,284 @@
+// Package fieldcrypt encrypts personal data fields before they are stored.
+//
+// Values are sealed with envelope encryption: a random data key encrypts
+// them with AES-256-GCM and a KeyProvider wraps the data key with a master
+// key that never leaves the provider. Every sealed value carries the ID of
+// the master key and the wrapped data key, so it can still be opened after
+// the master key has been rotated.
+//
+// Encrypted fields cannot be compared, so exact match lookups go through a
+// blind index instead, a keyed hash of the normalized value.
+package fieldcrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+// prefix marks sealed values, anything else is treated as plaintext
+const prefix = "enc:v1:"
+
+var (
+	ErrMalformed  = errors.New("malformed encrypted value")
+	ErrKeyUnknown = errors.New("unknown master key")
+)
+
+// KeyProvider wraps data keys with a master key, e.g. a local key file or
+// a key management service. Implementations must be safe for concurrent use.
+type KeyProvider interface {
+	// Wrap encrypts dataKey with the current master key and returns that
+	// key's ID. IDs must not contain colons.
+	Wrap(dataKey []byte) (keyID string, wrapped []byte, err error)
+	// Unwrap decrypts a data key wrapped by master key keyID
+	Unwrap(keyID string, wrapped []byte) ([]byte, error)
+}
+
+// Rotator is implemented by providers that manage their own master keys.
+// Key management services usually rotate on their own schedule instead.
+type Rotator interface {
+	// Rotate creates a master key and makes it the current one. Older
+	// keys are kept so existing values can still be opened.
+	Rotate() (keyID string, err error)
+}
+
+type dataKey struct {
+	keyID   string
+	wrapped string
+	aead    cipher.AEAD
+}
+
+var (
+	mu       sync.RWMutex
+	provider KeyProvider
+	indexKey []byte
+	current  *dataKey
+	// Data keys already unwrapped, by key ID and wrapped key
+	opened = make(map[string]cipher.AEAD)
+
+	fallback sync.Once
+)
+
+// Configure sets the provider master keys come from and the key blind
+// indexes are computed with. The index key cannot be rotated without
+// recomputing every index, so it is kept apart from the master keys.
+func Configure(p KeyProvider, blindIndexKey []byte) error {
+	key, err := newDataKey(p)
+	if err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	provider = p
+	indexKey = blindIndexKey
+	current = key
+	opened = map[string]cipher.AEAD{key.keyID + ":" + key.wrapped: key.aead}
+	return nil
+}
+
+// Rotate starts encrypting with a new data key, after rotating the master
+// key if the provider supports it. Values sealed before keep working until
+// they are encrypted again. It returns the ID of the master key now in use.
+func Rotate() (string, error) {
+	p := configured()
+	if rotator, ok := p.(Rotator); ok {
+		if _, err := rotator.Rotate(); err != nil {
+			return "", fmt.Errorf("failed to rotate master key: %w", err)
+		}
+	}
+
+	key, err := newDataKey(p)
+	if err != nil {
+		return "", err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	current = key
+	opened[key.keyID+":"+key.wrapped] = key.aead
+	return key.keyID, nil
+}
+
+// CurrentKeyID returns the ID of the master key new values are sealed with
+func CurrentKeyID() string {
+	configured()
+	mu.RLock()
+	defer mu.RUnlock()
+	return current.keyID
+}
+
+// Encrypt seals value with the current data key. Empty values stay empty
+// so optional fields remain recognizable.
+func Encrypt(value string) string {
+	if value == "" {
+		return ""
+	}
+	configured()
+	mu.RLock()
+	key := current
+	mu.RUnlock()
+
+	nonce := make([]byte, key.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		// crypto/rand only fails when the system is unusable
+		panic("fieldcrypt: failed to generate nonce: " + err.Error())
+	}
+	sealed := key.aead.Seal(nonce, nonce, []byte(value), nil)
+	return prefix + key.keyID + ":" + key.wrapped + ":" + base64.RawStdEncoding.EncodeToString(sealed)
+}
+
+// Decrypt opens a value sealed by Encrypt. Values without the encryption
+// prefix are returned as they are, so data stored before encryption was
+// enabled stays readable until it is written again.
+func Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", ErrMalformed
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", ErrMalformed
+	}
+
+	aead, err := openDataKey(parts[0], parts[1])
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", ErrMalformed
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// IsEncrypted reports whether value was sealed by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// KeyID returns the master key a sealed value depends on, or an empty
+// string for plaintext
+func KeyID(value string) string {
+	if !IsEncrypted(value) {
+		return ""
+	}
+	keyID, _, _ := strings.Cut(strings.TrimPrefix(value, prefix), ":")
+	return keyID
+}
+
+// BlindIndex returns a keyed hash of value for exact match lookups. The
+// caller normalizes value first, e.g. lowercases email addresses.
+func BlindIndex(value string) string {
+	if value == "" {
+		return ""
+	}
+	configured()
+	mu.RLock()
+	defer mu.RUnlock()
+	mac := hmac.New(sha256.New, indexKey)
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// configured returns the provider, falling back to random keys that only
+// live as long as the process when Configure was not called
+func configured() KeyProvider {
+	fallback.Do(func() {
+		mu.RLock()
+		p := provider
+		mu.RUnlock()
+		if p != nil {
+			return
+		}
+
+		log.Println("WARNING: field encryption is not configured, using temporary keys")
+		ephemeral := NewEphemeralKeyProvider()
+		if err := Configure(ephemeral, ephemeral.BlindIndexKey()); err != nil {
+			panic("fieldcrypt: " + err.Error())
+		}
+	})
+
+	mu.RLock()
+	defer mu.RUnlock()
+	return provider
+}
+
+func newDataKey(p KeyProvider) (*dataKey, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+	keyID, wrapped, err := p.Wrap(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	if keyID == "" || strings.Contains(keyID, ":") {
+		return nil, fmt.Errorf("invalid master key ID %q", keyID)
+	}
+	aead, err := newAEAD(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &dataKey{
+		keyID:   keyID,
+		wrapped: base64.RawStdEncoding.EncodeToString(wrapped),
+		aead:    aead,
+	}, nil
+}
+
+func openDataKey(keyID, wrapped string) (cipher.AEAD, error) {
+	cacheKey := keyID + ":" + wrapped
+	mu.RLock()
+	aead, ok := opened[cacheKey]
+	p := provider
+	mu.RUnlock()
+	if ok {
+		return aead, nil
+	}
+	if p == nil {
+		return nil, ErrKeyUnknown
+	}
+
+	raw, err := base64.RawStdEncoding.DecodeString(wrapped)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	key, err := p.Unwrap(keyID, raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	aead, err = newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	opened[cacheKey] = aead
+	mu.Unlock()
+	return aead, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,205 @@
+package fieldcrypt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useKeyFile configures a key file in a temporary directory
+func useKeyFile(t *testing.T) (*LocalKeyProvider, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "keys.json")
+	p, err := LoadKeyFile(path)
+	if err != nil {
+		t.Fatalf("LoadKeyFile: %v", err)
+	}
+	if err := Configure(p, p.BlindIndexKey()); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	return p, path
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	useKeyFile(t)
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"ascii", "alice@example.com"},
+		{"unicode", "Zoë Ñúñez"},
+		{"contains colons", "a:b:c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sealed := Encrypt(tt.value)
+			if !IsEncrypted(sealed) || strings.Contains(sealed, tt.value) {
+				t.Fatalf("Encrypt(%q) = %q, want a sealed value", tt.value, sealed)
+			}
+			if Encrypt(tt.value) == sealed {
+				t.Error("Encrypt() is deterministic, want a random nonce")
+			}
+			got, err := Decrypt(sealed)
+			if err != nil || got != tt.value {
+				t.Errorf("Decrypt() = %q, %v, want %q", got, err, tt.value)
+			}
+		})
+	}
+}
+
+func TestEncryptPassthrough(t *testing.T) {
+	useKeyFile(t)
+
+	if got := Encrypt(""); got != "" {
+		t.Errorf("Encrypt(\"\") = %q, want empty", got)
+	}
+	// Values stored before encryption was enabled stay readable
+	if got, err := Decrypt("plain@example.com"); err != nil || got != "plain@example.com" {
+		t.Errorf("Decrypt(plaintext) = %q, %v", got, err)
+	}
+	if got := KeyID("plain@example.com"); got != "" {
+		t.Errorf("KeyID(plaintext) = %q, want empty", got)
+	}
+}
+
+func TestDecryptRejectsTampering(t *testing.T) {
+	useKeyFile(t)
+	sealed := Encrypt("alice@example.com")
+	parts := strings.Split(strings.TrimPrefix(sealed, prefix), ":")
+
+	flipped := []byte(parts[2])
+	if flipped[len(flipped)/2] == 'A' {
+		flipped[len(flipped)/2] = 'B'
+	} else {
+		flipped[len(flipped)/2] = 'A'
+	}
+
+	tests := []struct {
+		name    string
+		value   string
+		wantErr error
+	}{
+		{"modified ciphertext", prefix + parts[0] + ":" + parts[1] + ":" + string(flipped), nil},
+		{"missing part", prefix + parts[0] + ":" + parts[2], ErrMalformed},
+		{"invalid base64", prefix + parts[0] + ":" + parts[1] + ":!!", ErrMalformed},
+		{"unknown master key", prefix + "0000000000000000:" + parts[1] + ":" + parts[2], ErrKeyUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decrypt(tt.value)
+			if err == nil {
+				t.Fatal("Decrypt() succeeded, want an error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("Decrypt() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRotate(t *testing.T) {
+	_, path := useKeyFile(t)
+	before := Encrypt("alice@example.com")
+	oldKeyID := CurrentKeyID()
+
+	newKeyID, err := Rotate()
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if newKeyID == oldKeyID || CurrentKeyID() != newKeyID {
+		t.Fatalf("key after Rotate = %q, want a new key, was %q", CurrentKeyID(), oldKeyID)
+	}
+
+	after := Encrypt("alice@example.com")
+	if KeyID(before) != oldKeyID || KeyID(after) != newKeyID {
+		t.Errorf("KeyID() = %q and %q, want %q and %q", KeyID(before), KeyID(after), oldKeyID, newKeyID)
+	}
+
+	// The rotated key file still opens values sealed with either key
+	reloaded, err := LoadKeyFile(path)
+	if err != nil {
+		t.Fatalf("LoadKeyFile after Rotate: %v", err)
+	}
+	if err := Configure(reloaded, reloaded.BlindIndexKey()); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	for _, sealed := range []string{before, after} {
+		if got, err := Decrypt(sealed); err != nil || got != "alice@example.com" {
+			t.Errorf("Decrypt() after reload = %q, %v", got, err)
+		}
+	}
+}
+
+func TestLoadKeyFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "keys.json")
+
+	created, err := LoadKeyFile(path)
+	if err != nil {
+		t.Fatalf("LoadKeyFile: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm()&0o077 != 0 {
+		t.Errorf("key file mode = %v, %v, want readable by the owner only", info.Mode(), err)
+	}
+	loaded, err := LoadKeyFile(path)
+	if err != nil {
+		t.Fatalf("LoadKeyFile of existing file: %v", err)
+	}
+	if string(loaded.BlindIndexKey()) != string(created.BlindIndexKey()) || loaded.keys.CurrentKeyID != created.keys.CurrentKeyID {
+		t.Error("reloaded key file differs from the created one")
+	}
+
+	invalid := []struct {
+		name    string
+		content string
+	}{
+		{"not json", "keys"},
+		{"no current key", `{"current_key_id":"missing","master_keys":[],"blind_index_key":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="}`},
+		{"short index key", `{"current_key_id":"a","master_keys":[{"id":"a","key":"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="}],"blind_index_key":"AAAA"}`},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, strings.ReplaceAll(tt.name, " ", "_")+".json")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadKeyFile(path); err == nil {
+				t.Error("LoadKeyFile() succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	useKeyFile(t)
+
+	index := BlindIndex("alice@example.com")
+	if index == "" || index != BlindIndex("alice@example.com") {
+		t.Fatalf("BlindIndex() = %q, want a stable value", index)
+	}
+	if BlindIndex("bob@example.com") == index {
+		t.Error("different values share an index")
+	}
+	if BlindIndex("") != "" {
+		t.Error("BlindIndex(\"\") is not empty")
+	}
+
+	// Rotating master keys leaves the index key alone
+	if _, err := Rotate(); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if BlindIndex("alice@example.com") != index {
+		t.Error("index changed after Rotate")
+	}
+
+	other := NewEphemeralKeyProvider()
+	if err := Configure(other, other.BlindIndexKey()); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	if BlindIndex("alice@example.com") == index {
+		t.Error("index does not depend on the key")
+	}
+}
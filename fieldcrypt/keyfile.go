@@ -0,0 +1,200 @@
+package fieldcrypt
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// LocalKeyProvider keeps the master keys and the blind index key in a JSON
+// file. It suits single instance deployments, shared deployments should
+// use a key management service so the keys never sit next to the data.
+type LocalKeyProvider struct {
+	mu   sync.RWMutex
+	path string
+	keys keyFile
+}
+
+type keyFile struct {
+	CurrentKeyID  string      `json:"current_key_id"`
+	MasterKeys    []masterKey `json:"master_keys"`
+	BlindIndexKey []byte      `json:"blind_index_key"`
+}
+
+type masterKey struct {
+	ID        string    `json:"id"`
+	Key       []byte    `json:"key"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LoadKeyFile reads the keys stored at path, creating the file with new
+// keys when it does not exist yet
+func LoadKeyFile(path string) (*LocalKeyProvider, error) {
+	p := &LocalKeyProvider{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		if err := p.init(); err != nil {
+			return nil, err
+		}
+		return p, p.save()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &p.keys); err != nil {
+		return nil, fmt.Errorf("invalid key file %s: %w", path, err)
+	}
+	if _, ok := p.masterKey(p.keys.CurrentKeyID); !ok {
+		return nil, fmt.Errorf("invalid key file %s: current key %q not found", path, p.keys.CurrentKeyID)
+	}
+	if len(p.keys.BlindIndexKey) < 32 {
+		return nil, fmt.Errorf("invalid key file %s: blind index key is too short", path)
+	}
+	return p, nil
+}
+
+// NewEphemeralKeyProvider returns a provider with random keys that are
+// never written to disk
+func NewEphemeralKeyProvider() *LocalKeyProvider {
+	p := &LocalKeyProvider{}
+	if err := p.init(); err != nil {
+		panic("fieldcrypt: " + err.Error())
+	}
+	return p
+}
+
+// BlindIndexKey returns the key blind indexes are computed with
+func (p *LocalKeyProvider) BlindIndexKey() []byte {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.keys.BlindIndexKey
+}
+
+// Wrap encrypts dataKey with the current master key
+func (p *LocalKeyProvider) Wrap(dataKey []byte) (string, []byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	key, _ := p.masterKey(p.keys.CurrentKeyID)
+	aead, err := newAEAD(key.Key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, err
+	}
+	return key.ID, aead.Seal(nonce, nonce, dataKey, []byte(key.ID)), nil
+}
+
+// Unwrap decrypts a data key wrapped by master key keyID
+func (p *LocalKeyProvider) Unwrap(keyID string, wrapped []byte) ([]byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	key, ok := p.masterKey(keyID)
+	if !ok {
+		return nil, ErrKeyUnknown
+	}
+	aead, err := newAEAD(key.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(wrapped) < aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	nonce, ciphertext := wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, []byte(key.ID))
+}
+
+// Rotate adds a master key and makes it the current one. The previous keys
+// stay in the file until nothing is sealed with them anymore.
+func (p *LocalKeyProvider) Rotate() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	previous := p.keys
+	if err := p.addMasterKey(); err != nil {
+		return "", err
+	}
+	if err := p.save(); err != nil {
+		p.keys = previous
+		return "", err
+	}
+	return p.keys.CurrentKeyID, nil
+}
+
+func (p *LocalKeyProvider) init() error {
+	p.keys.BlindIndexKey = make([]byte, 32)
+	if _, err := rand.Read(p.keys.BlindIndexKey); err != nil {
+		return fmt.Errorf("failed to generate blind index key: %w", err)
+	}
+	return p.addMasterKey()
+}
+
+func (p *LocalKeyProvider) addMasterKey() error {
+	id := make([]byte, 8)
+	key := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Errorf("failed to generate master key: %w", err)
+	}
+	if _, err := rand.Read(key); err != nil {
+		return fmt.Errorf("failed to generate master key: %w", err)
+	}
+
+	// Copy so a failed save can restore the previous slice untouched
+	keys := append([]masterKey{}, p.keys.MasterKeys...)
+	p.keys.MasterKeys = append(keys, masterKey{
+		ID:        hex.EncodeToString(id),
+		Key:       key,
+		CreatedAt: time.Now(),
+	})
+	p.keys.CurrentKeyID = hex.EncodeToString(id)
+	return nil
+}
+
+func (p *LocalKeyProvider) masterKey(id string) (masterKey, bool) {
+	for _, key := range p.keys.MasterKeys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+	return masterKey{}, false
+}
+
+// save replaces the key file in one step so a crash cannot leave it half
+// written. Ephemeral providers have no path and are not saved.
+func (p *LocalKeyProvider) save() error {
+	if p.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(p.keys, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p.path), filepath.Base(p.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p.path)
+}
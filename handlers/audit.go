@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"hr-backend-system/audit"
+	"hr-backend-system/middleware"
+	"hr-backend-system/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAuditLog godoc
+// @Summary List audit entries
+// @Description Retrieve recorded user mutations, newest first. Each entry holds the actor, action, changed user, field changes, IP address and request ID. Credentials are only marked as changed.
+// @Tags audit
+// @Accept json
+// @Produce json
+// @Param action query string false "Action, e.g. user.updated"
+// @Param actor_id query int false "User who made the change"
+// @Param target_id query int false "Changed user"
+// @Param request_id query string false "Request ID"
+// @Param from query string false "Earliest time, RFC 3339"
+// @Param to query string false "Latest time (exclusive), RFC 3339"
+// @Param limit query int false "Maximum number of entries" default(50)
+// @Success 200 {object} models.APIResponse{data=[]models.AuditEntry}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /audit [get]
+func GetAuditLog(c *gin.Context) {
+	filter := models.AuditFilter{
+		Action:    c.Query("action"),
+		RequestID: c.Query("request_id"),
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 {
+		limit = 50
+	}
+	if limit > 500 {
+		limit = 500
+	}
+	filter.Limit = limit
+
+	for name, target := range map[string]*int{"actor_id": &filter.ActorID, "target_id": &filter.TargetID} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil || id < 1 {
+			invalidAuditFilter(c, name)
+			return
+		}
+		*target = id
+	}
+
+	for name, target := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			invalidAuditFilter(c, name)
+			return
+		}
+		*target = t
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Audit entries retrieved successfully",
+		Data:    audit.List(filter),
+	})
+}
+
+func invalidAuditFilter(c *gin.Context, name string) {
+	c.JSON(http.StatusBadRequest, models.APIResponse{
+		Success: false,
+		Message: "Invalid " + name + " filter",
+		Error:   "invalid_filter",
+	})
+}
+
+// recordUserAudit records a change to a user account made by the current
+// request. before is nil for new accounts and after is nil for deleted ones.
+// Unauthenticated requests, e.g. registration or password reset, are
+// attributed to the account itself.
+func recordUserAudit(c *gin.Context, action string, before, after *models.User) {
+	targetID := 0
+	if after != nil {
+		targetID = after.ID
+	} else if before != nil {
+		targetID = before.ID
+	}
+	recordAuditEntry(c, action, targetID, audit.UserChanges(before, after))
+}
+
+// recordAuditEntry records a change to the target user made by the current
+// request, attributed like recordUserAudit. targetID is zero for changes
+// that do not concern a single user, e.g. settings.
+func recordAuditEntry(c *gin.Context, action string, targetID int, changes []models.FieldChange) {
+	entry := models.AuditEntry{
+		Action:    action,
+		TargetID:  targetID,
+		ActorID:   targetID,
+		Changes:   changes,
+		IP:        c.ClientIP(),
+		RequestID: middleware.GetRequestID(c),
+	}
+
+	if currentUser, ok := middleware.GetCurrentUser(c); ok {
+		entry.ActorID = currentUser.ID
+	}
+	if key, ok := middleware.GetAPIKey(c); ok {
+		entry.APIKeyID = key.ID
+	}
+	if impersonator, ok := middleware.GetImpersonator(c); ok {
+		entry.ImpersonatorID = impersonator.ID
+	}
+
+	audit.Record(entry)
+}
@@ -1 +1,415 @@
 package handlers
+
+import (
+	"errors"
+	"hr-backend-system/config"
+	"hr-backend-system/lockout"
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"hr-backend-system/utils"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Register godoc
+// @Summary Register a new account
+// @Description Public sign-up for job seekers and organizations. Staff roles cannot be self-assigned.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param user body models.RegisterRequest true "Registration request"
+// @Success 201 {object} models.APIResponse{data=models.UserResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Router /auth/register [post]
+func Register(c *gin.Context) {
+	var req models.RegisterRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// Binding already limits the type, check again so staff roles can
+	// never be self-assigned even if the request model changes
+	if !models.IsSelfRegisterableType(req.Type) {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "This account type cannot be registered",
+			Error:   "invalid_user_type",
+		})
+		return
+	}
+
+	if strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Name is required",
+			Error:   "missing_name",
+		})
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if _, exists := storage.GetUserByEmail(email); exists {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "User with this email already exists",
+			Error:   "duplicate_email",
+		})
+		return
+	}
+
+	if !checkPasswordPolicy(c, req.Password, req.Name, email) {
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(req.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to process password",
+			Error:   "password_hash_error",
+		})
+		return
+	}
+
+	newUser := models.User{
+		ID:          storage.GetNextUserID(),
+		Name:        strings.TrimSpace(req.Name),
+		Email:       email,
+		PhoneNumber: strings.TrimSpace(req.PhoneNumber),
+		Type:        req.Type,
+		Password:    hashedPassword,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+
+	storage.AddUser(newUser)
+	recordUserAudit(c, models.AuditUserRegistered, nil, &newUser)
+	sendVerificationEmail(newUser, newUser.Email)
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Registration successful",
+		Data:    newUser.ToResponse(),
+	})
+}
+
+// Login godoc
+// @Summary Log in with email and password
+// @Description Authenticate a user and return a signed access token and a refresh token. Accounts with two-factor authentication receive a challenge token for /auth/2fa/verify instead.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param credentials body models.LoginRequest true "Login credentials"
+// @Success 200 {object} models.APIResponse{data=models.LoginResponse}
+// @Success 202 {object} models.APIResponse{data=models.TwoFactorChallengeResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 429 {object} models.APIResponse
+// @Router /auth/login [post]
+func Login(c *gin.Context) {
+	var req models.LoginRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// Emails are stored lowercased, see CreateUser
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	if !allowCredentialCheck(c, email) {
+		return
+	}
+
+	// Use the same response for unknown email and wrong password, and check
+	// a dummy hash for unknown ones so the time taken is the same as well.
+	// Either way the endpoint does not reveal which accounts exist.
+	user, exists := storage.GetUserByEmail(email)
+	hash := user.Password
+	if !exists || hash == "" {
+		hash = utils.DummyPasswordHash()
+	}
+	if !utils.CheckPassword(hash, req.Password) || !exists || user.Password == "" {
+		lockout.RecordFailure(email, c.ClientIP())
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Invalid email or password",
+			Error:   "invalid_credentials",
+		})
+		return
+	}
+
+	if storage.GetSettings().RequireEmailVerification && !user.EmailVerified {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "Please verify your email address before logging in",
+			Error:   "email_not_verified",
+		})
+		return
+	}
+
+	// An outdated hash is only replaced once the login is accepted. With
+	// two-factor authentication that is the second step, so the new hash
+	// waits in the challenge. The failures are cleared then as well.
+	newHash := upgradedPasswordHash(user, req.Password)
+	if !user.TwoFactorEnabled {
+		lockout.RecordSuccess(email)
+		if newHash != "" {
+			replacePasswordHash(&user, newHash)
+			storage.UpdateUser(user.ID, user)
+		}
+	}
+
+	completeLogin(c, user, newHash)
+}
+
+// RefreshToken godoc
+// @Summary Refresh an access token
+// @Description Exchange a refresh token for a new access token and a new refresh token. Each refresh token can be used once; reusing one revokes the whole session.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshRequest true "Refresh token"
+// @Success 200 {object} models.APIResponse{data=models.LoginResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Router /auth/refresh [post]
+func RefreshToken(c *gin.Context) {
+	var req models.RefreshRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	token, err := storage.UseRefreshToken(utils.HashToken(req.RefreshToken))
+	if errors.Is(err, storage.ErrRefreshTokenReused) {
+		// A rotated token was presented again, so it may have been stolen.
+		// Revoke the whole family to cut off whoever holds the latest token.
+		storage.RevokeSession(token.SessionID)
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Refresh token has already been used, session revoked",
+			Error:   "refresh_token_reused",
+		})
+		return
+	}
+	if err != nil || time.Now().After(token.ExpiresAt) || !storage.IsSessionActive(token.SessionID) {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Invalid or expired refresh token",
+			Error:   "invalid_refresh_token",
+		})
+		return
+	}
+
+	user, exists := storage.GetUserByID(token.UserID)
+	if !exists {
+		storage.RevokeSession(token.SessionID)
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "User no longer exists",
+			Error:   "invalid_refresh_token",
+		})
+		return
+	}
+
+	storage.TouchSession(token.SessionID, c.ClientIP())
+
+	tokens, err := issueTokens(user, token.SessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to generate tokens",
+			Error:   "token_generation_error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Token refreshed successfully",
+		Data:    tokens,
+	})
+}
+
+// Logout godoc
+// @Summary Log out
+// @Description Revoke the session the refresh token belongs to
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.RefreshRequest true "Refresh token"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Router /auth/logout [post]
+func Logout(c *gin.Context) {
+	var req models.RefreshRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// Logging out is idempotent, unknown or already revoked tokens succeed too
+	if token, err := storage.UseRefreshToken(utils.HashToken(req.RefreshToken)); err == nil || errors.Is(err, storage.ErrRefreshTokenReused) {
+		storage.RevokeSession(token.SessionID)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Logged out successfully",
+	})
+}
+
+// completeLogin finishes a successful first login step. Accounts with
+// two-factor authentication get a short-lived challenge instead of tokens,
+// see VerifyTwoFactorLogin.
+func completeLogin(c *gin.Context, user models.User, newPasswordHash string) {
+	if user.TwoFactorEnabled {
+		challenge, err := createTwoFactorChallenge(user, newPasswordHash)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to create two-factor challenge",
+				Error:   "token_generation_error",
+			})
+			return
+		}
+
+		c.JSON(http.StatusAccepted, models.APIResponse{
+			Success: true,
+			Message: "Two-factor authentication required",
+			Data:    challenge,
+		})
+		return
+	}
+
+	tokens, err := createSession(c, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to generate tokens",
+			Error:   "token_generation_error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Login successful",
+		Data:    tokens,
+	})
+}
+
+// upgradedPasswordHash returns a new hash of password, which was just
+// checked against the stored hash, when the stored one was made with another
+// algorithm or weaker parameters than the current default, e.g. bcrypt
+// hashes from before argon2id was introduced. Otherwise it returns "".
+func upgradedPasswordHash(user models.User, password string) string {
+	if !utils.PasswordNeedsRehash(user.Password) {
+		return ""
+	}
+
+	hashedPassword, err := utils.HashPassword(password)
+	if err != nil {
+		log.Printf("rehash password for user %d: %v", user.ID, err)
+		return ""
+	}
+	return hashedPassword
+}
+
+// replacePasswordHash swaps the stored hash for an upgraded hash of the
+// same password
+func replacePasswordHash(user *models.User, hashedPassword string) {
+	// Keep the history pointing at the current password so reuse checks still match
+	history := make([]string, len(user.PasswordHistory))
+	for i, hash := range user.PasswordHistory {
+		history[i] = hash
+		if hash == user.Password {
+			history[i] = hashedPassword
+		}
+	}
+	user.PasswordHistory = history
+	user.Password = hashedPassword
+}
+
+// createSession starts a new session for the user from the requesting
+// device and issues its first tokens
+func createSession(c *gin.Context, user models.User) (models.LoginResponse, error) {
+	sessionID, err := utils.GenerateID()
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	now := time.Now()
+	storage.AddSession(models.Session{
+		ID:         sessionID,
+		UserID:     user.ID,
+		UserAgent:  c.Request.UserAgent(),
+		IP:         c.ClientIP(),
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(config.Get().RefreshTokenTTL),
+	})
+
+	return issueTokens(user, sessionID)
+}
+
+// issueTokens creates an access token and a new refresh token for a session
+func issueTokens(user models.User, sessionID string) (models.LoginResponse, error) {
+	accessToken, expiresAt, err := utils.GenerateAccessToken(user, sessionID)
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	refreshToken, err := utils.GenerateRandomToken()
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+
+	now := time.Now()
+	refreshExpiresAt := now.Add(config.Get().RefreshTokenTTL)
+	storage.AddRefreshToken(models.RefreshToken{
+		TokenHash: utils.HashToken(refreshToken),
+		SessionID: sessionID,
+		UserID:    user.ID,
+		ExpiresAt: refreshExpiresAt,
+		CreatedAt: now,
+	})
+
+	return models.LoginResponse{
+		AccessToken:           accessToken,
+		TokenType:             "Bearer",
+		ExpiresIn:             int(config.Get().AccessTokenTTL.Seconds()),
+		ExpiresAt:             expiresAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: refreshExpiresAt,
+		User:                  user.ToResponse(),
+	}, nil
+}
@@ -0,0 +1,47 @@
+package handlers_test
+
+import (
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"net/http"
+	"testing"
+)
+
+func TestUsersRouteAuthentication(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      func(t *testing.T) string
+		method     string
+		wantStatus int
+		wantError  string
+	}{
+		{"no token", func(t *testing.T) string { return "" }, http.MethodGet, http.StatusUnauthorized, "missing_token"},
+		{"malformed token", func(t *testing.T) string { return "not-a-jwt" }, http.MethodGet, http.StatusUnauthorized, "invalid_token"},
+		{"revoked session", func(t *testing.T) string {
+			user := createUser(t, models.UserTypeViewer)
+			token := loginAs(t, user)
+			storage.RevokeUserSessions(user.ID)
+			return token
+		}, http.MethodGet, http.StatusUnauthorized, "session_revoked"},
+		{"deleted user", func(t *testing.T) string {
+			user := createUser(t, models.UserTypeViewer)
+			token := loginAs(t, user)
+			storage.DeleteUser(user.ID)
+			return token
+		}, http.MethodGet, http.StatusUnauthorized, "invalid_token"},
+		{"viewer reads", func(t *testing.T) string { return loginAs(t, createUser(t, models.UserTypeViewer)) }, http.MethodGet, http.StatusOK, ""},
+		{"viewer creates", func(t *testing.T) string { return loginAs(t, createUser(t, models.UserTypeViewer)) }, http.MethodPost, http.StatusForbidden, "forbidden"},
+		{"jobseeker reads", func(t *testing.T) string { return loginAs(t, createUser(t, models.UserTypeJobSeeker)) }, http.MethodGet, http.StatusForbidden, "forbidden"},
+		{"organization reads", func(t *testing.T) string { return loginAs(t, createUser(t, models.UserTypeOrganization)) }, http.MethodGet, http.StatusForbidden, "forbidden"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body any
+			if tt.method == http.MethodPost {
+				body = map[string]any{}
+			}
+			w := request(t, tt.method, "/users", tt.token(t), body)
+			expect(t, w, tt.wantStatus, tt.wantError)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"hr-backend-system/config"
+	"hr-backend-system/dataexport"
+	"hr-backend-system/middleware"
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportUser godoc
+// @Summary Export a user's personal data
+// @Description Start generating a bundle of the user record, sessions, linked accounts, API keys, ownership transfers, role change requests, security events and audit entries. The export is generated in the background; poll the returned export until its status is ready, then fetch download_url. A pending export of the same format is returned instead of starting another. Users can export their own data, exporting others needs the users:export permission and is limited to accounts below the caller's role. Not available while impersonating.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param format query string false "Bundle format" Enums(zip, json) default(zip)
+// @Success 202 {object} models.APIResponse{data=models.DataExport}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /users/{id}/export [get]
+func ExportUser(c *gin.Context) {
+	format := c.DefaultQuery("format", models.DataExportFormatZIP)
+	if format != models.DataExportFormatZIP && format != models.DataExportFormatJSON {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Format must be zip or json",
+			Error:   "invalid_format",
+		})
+		return
+	}
+
+	user, ok := getUserFromParam(c)
+	if !ok || !checkExportAccess(c, user) {
+		return
+	}
+
+	var export models.DataExport
+	for _, existing := range storage.GetUserDataExports(user.ID) {
+		if existing.Status == models.DataExportPending && existing.Format == format {
+			export = existing
+			break
+		}
+	}
+
+	if export.ID == "" {
+		currentUser, _ := middleware.GetCurrentUser(c)
+		var err error
+		export, err = dataexport.Start(user, currentUser.ID, format)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, models.APIResponse{
+				Success: false,
+				Message: "Failed to start data export",
+				Error:   "internal_error",
+			})
+			return
+		}
+	}
+
+	c.Header("Location", exportURL(export))
+	c.JSON(http.StatusAccepted, models.APIResponse{
+		Success: true,
+		Message: "Data export started, it can be downloaded once ready",
+		Data:    withDownloadURL(export),
+	})
+}
+
+// GetUserExport godoc
+// @Summary Get a data export
+// @Description Retrieve the status of a personal data export. Ready exports include a download_url until they expire.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param exportId path string true "Export ID"
+// @Success 200 {object} models.APIResponse{data=models.DataExport}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /users/{id}/exports/{exportId} [get]
+func GetUserExport(c *gin.Context) {
+	export, ok := getUserExport(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Data export retrieved successfully",
+		Data:    withDownloadURL(export),
+	})
+}
+
+// DownloadUserExport godoc
+// @Summary Download a data export
+// @Description Download a ready personal data export as a ZIP archive or JSON document
+// @Tags users
+// @Produce application/zip
+// @Produce json
+// @Param id path int true "User ID"
+// @Param exportId path string true "Export ID"
+// @Success 200 {file} file
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /users/{id}/exports/{exportId}/download [get]
+func DownloadUserExport(c *gin.Context) {
+	export, ok := getUserExport(c)
+	if !ok {
+		return
+	}
+
+	if export.Status != models.DataExportReady {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "Data export is not ready",
+			Error:   "export_not_ready",
+		})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.FileAttachment(export.FilePath, "user-"+strconv.Itoa(export.UserID)+"-export."+export.Format)
+}
+
+// checkExportAccess allows users to export their own data and callers with
+// the users:export permission to export the data of accounts they manage
+func checkExportAccess(c *gin.Context, user models.User) bool {
+	currentUser, _ := middleware.GetCurrentUser(c)
+	if currentUser.ID == user.ID {
+		return true
+	}
+
+	if !storage.RoleHasPermission(middleware.GetCurrentRole(c), models.PermUsersExport) {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "You do not have permission to perform this action",
+			Error:   "forbidden",
+		})
+		return false
+	}
+	return checkManageUser(c, user)
+}
+
+// getUserExport loads the unexpired export named in the URL after checking
+// the caller may access the user's data
+func getUserExport(c *gin.Context) (models.DataExport, bool) {
+	user, ok := getUserFromParam(c)
+	if !ok || !checkExportAccess(c, user) {
+		return models.DataExport{}, false
+	}
+
+	export, exists := storage.GetDataExport(c.Param("exportId"))
+	if !exists || export.UserID != user.ID || !time.Now().Before(export.ExpiresAt) {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Data export not found",
+			Error:   "export_not_found",
+		})
+		return models.DataExport{}, false
+	}
+	return export, true
+}
+
+func exportURL(export models.DataExport) string {
+	return config.Get().APIBaseURL + "/api/v1/users/" + strconv.Itoa(export.UserID) + "/exports/" + export.ID
+}
+
+func withDownloadURL(export models.DataExport) models.DataExport {
+	if export.Status == models.DataExportReady {
+		export.DownloadURL = exportURL(export) + "/download"
+	}
+	return export
+}
@@ -0,0 +1,142 @@
+package handlers_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startExport starts an export of user and waits until it is generated
+func startExport(t *testing.T, token string, user models.User, format string) string {
+	t.Helper()
+	w := request(t, http.MethodGet, userPath(user.ID, "/export?format="+format), token, nil)
+	expect(t, w, http.StatusAccepted, "")
+	exportID := decode(t, w).Data.(map[string]any)["id"].(string)
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if export, _ := storage.GetDataExport(exportID); export.Status != models.DataExportPending {
+			return exportID
+		}
+	}
+	t.Fatalf("export %s still pending", exportID)
+	return ""
+}
+
+func TestExportAccess(t *testing.T) {
+	admin := createUser(t, models.UserTypeAdmin)
+	viewer := createUser(t, models.UserTypeViewer)
+	adminToken := loginAs(t, admin)
+
+	tests := []struct {
+		name       string
+		caller     models.User
+		target     models.User
+		wantStatus int
+		wantError  string
+	}{
+		{"own data", viewer, viewer, http.StatusAccepted, ""},
+		{"lower role", admin, createUser(t, models.UserTypeOperator), http.StatusAccepted, ""},
+		{"external account", admin, createUser(t, models.UserTypeJobSeeker), http.StatusAccepted, ""},
+		{"owner", admin, createUser(t, models.UserTypeOwner), http.StatusForbidden, "insufficient_role"},
+		{"same role", admin, createUser(t, models.UserTypeAdmin), http.StatusForbidden, "insufficient_role"},
+		{"no export permission", viewer, createUser(t, models.UserTypeJobSeeker), http.StatusForbidden, "forbidden"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := adminToken
+			if tt.caller.ID != admin.ID {
+				token = loginAs(t, tt.caller)
+			}
+			w := request(t, http.MethodGet, userPath(tt.target.ID, "/export?format=json"), token, nil)
+			expect(t, w, tt.wantStatus, tt.wantError)
+		})
+	}
+}
+
+func TestExportRoutesBlockImpersonation(t *testing.T) {
+	admin := createUser(t, models.UserTypeAdmin)
+	target := createUser(t, models.UserTypeJobSeeker)
+	adminToken := loginAs(t, admin)
+
+	exportID := startExport(t, adminToken, target, models.DataExportFormatJSON)
+
+	token := impersonate(t, adminToken, target)
+	for _, suffix := range []string{"/export", "/exports/" + exportID, "/exports/" + exportID + "/download"} {
+		t.Run(suffix, func(t *testing.T) {
+			expect(t, request(t, http.MethodGet, userPath(target.ID, suffix), token, nil), http.StatusForbidden, "impersonation_forbidden")
+		})
+	}
+
+	// The admin can still fetch it directly
+	w := request(t, http.MethodGet, userPath(target.ID, "/exports/"+exportID+"/download"), adminToken, nil)
+	if w.Code != http.StatusOK {
+		t.Errorf("download status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestExportDownload(t *testing.T) {
+	user := createUser(t, models.UserTypeViewer)
+	secret := enableTwoFactor(t, &user)
+	token := loginAs(t, user)
+	other := createUser(t, models.UserTypeViewer)
+	otherToken := loginAs(t, other)
+
+	t.Run("json", func(t *testing.T) {
+		exportID := startExport(t, token, user, models.DataExportFormatJSON)
+		w := request(t, http.MethodGet, userPath(user.ID, "/exports/"+exportID+"/download"), token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+		}
+		if w.Header().Get("Cache-Control") != "no-store" {
+			t.Error("download may be cached")
+		}
+		body := w.Body.String()
+		if !strings.Contains(body, user.Email) {
+			t.Error("export does not contain the user's email")
+		}
+		for name, secret := range map[string]string{"password hash": reload(t, user).Password, "TOTP secret": secret} {
+			if strings.Contains(body, secret) {
+				t.Errorf("export contains the %s", name)
+			}
+		}
+	})
+
+	t.Run("zip", func(t *testing.T) {
+		exportID := startExport(t, token, user, models.DataExportFormatZIP)
+		w := request(t, http.MethodGet, userPath(user.ID, "/exports/"+exportID+"/download"), token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+		}
+		if _, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len())); err != nil {
+			t.Errorf("invalid zip: %v", err)
+		}
+	})
+
+	t.Run("access", func(t *testing.T) {
+		exportID := startExport(t, token, user, models.DataExportFormatJSON)
+		expired := models.DataExport{ID: "expired-export", UserID: user.ID, Status: models.DataExportReady, ExpiresAt: time.Now().Add(-time.Minute)}
+		storage.AddDataExport(expired)
+
+		tests := []struct {
+			name       string
+			token      string
+			path       string
+			wantStatus int
+			wantError  string
+		}{
+			{"another user's export", otherToken, userPath(user.ID, "/exports/"+exportID+"/download"), http.StatusForbidden, "forbidden"},
+			{"under another user's path", otherToken, userPath(other.ID, "/exports/"+exportID+"/download"), http.StatusNotFound, "export_not_found"},
+			{"expired export", token, userPath(user.ID, "/exports/"+expired.ID+"/download"), http.StatusNotFound, "export_not_found"},
+			{"unknown format", token, userPath(user.ID, "/export?format=xml"), http.StatusBadRequest, "invalid_format"},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				expect(t, request(t, http.MethodGet, tt.path, tt.token, nil), tt.wantStatus, tt.wantError)
+			})
+		}
+	})
+}
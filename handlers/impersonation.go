@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"hr-backend-system/config"
+	"hr-backend-system/middleware"
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"hr-backend-system/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StartImpersonation godoc
+// @Summary Impersonate a user
+// @Description Issue a short-lived access token to act as a job seeker or organization account for support. The token cannot be refreshed, password, two-factor, ownership and deletion actions are blocked while it is used, and start and stop are recorded as security events.
+// @Tags security
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} models.APIResponse{data=models.ImpersonationResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /users/{id}/impersonate [post]
+func StartImpersonation(c *gin.Context) {
+	currentUser, _ := middleware.GetCurrentUser(c)
+
+	// Service accounts act with their API key scope and are never admins
+	// in person, so only interactive admin sessions may impersonate
+	if _, isAPIKey := middleware.GetAPIKey(c); isAPIKey || !currentUser.HasAdminAccess() {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "Only administrators can impersonate users",
+			Error:   "forbidden",
+		})
+		return
+	}
+
+	user, ok := getUserFromParam(c)
+	if !ok {
+		return
+	}
+
+	if !models.IsExternalType(user.Type) {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "Only job seeker and organization accounts can be impersonated",
+			Error:   "user_not_impersonatable",
+		})
+		return
+	}
+
+	sessionID, err := utils.GenerateID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to start impersonation",
+			Error:   "internal_error",
+		})
+		return
+	}
+
+	ttl := config.Get().ImpersonationTTL
+	now := time.Now()
+	storage.AddSession(models.Session{
+		ID:             sessionID,
+		UserID:         user.ID,
+		UserAgent:      c.Request.UserAgent(),
+		IP:             c.ClientIP(),
+		CreatedAt:      now,
+		LastSeenAt:     now,
+		ExpiresAt:      now.Add(ttl),
+		ImpersonatorID: currentUser.ID,
+	})
+
+	accessToken, expiresAt, err := utils.GenerateImpersonationToken(user, currentUser.ID, sessionID, ttl)
+	if err != nil {
+		storage.RevokeSession(sessionID)
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to start impersonation",
+			Error:   "internal_error",
+		})
+		return
+	}
+
+	storage.SecurityEvents().AddSecurityEvent(models.SecurityEvent{
+		Type:    models.SecurityEventImpersonationStarted,
+		Email:   user.Email,
+		IP:      c.ClientIP(),
+		ActorID: currentUser.ID,
+		UserID:  user.ID,
+		Details: "session " + sessionID,
+	})
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Impersonation started successfully",
+		Data: models.ImpersonationResponse{
+			AccessToken:    accessToken,
+			TokenType:      "Bearer",
+			ExpiresIn:      int(ttl.Seconds()),
+			ExpiresAt:      expiresAt,
+			ImpersonatorID: currentUser.ID,
+			User:           user.ToResponse(),
+		},
+	})
+}
+
+// StopImpersonation godoc
+// @Summary Stop impersonating
+// @Description End the impersonation session the request is made with. The impersonation token stops working immediately.
+// @Tags security
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /impersonation [delete]
+func StopImpersonation(c *gin.Context) {
+	impersonator, impersonating := middleware.GetImpersonator(c)
+	claims, _ := middleware.GetClaims(c)
+	if !impersonating || claims == nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "This request is not made with an impersonation token",
+			Error:   "not_impersonating",
+		})
+		return
+	}
+
+	user, _ := middleware.GetCurrentUser(c)
+	storage.RevokeSession(claims.SessionID)
+
+	storage.SecurityEvents().AddSecurityEvent(models.SecurityEvent{
+		Type:    models.SecurityEventImpersonationStopped,
+		Email:   user.Email,
+		IP:      c.ClientIP(),
+		ActorID: impersonator.ID,
+		UserID:  user.ID,
+		Details: "session " + claims.SessionID,
+	})
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Impersonation stopped successfully",
+	})
+}
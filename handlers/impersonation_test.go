@@ -0,0 +1,109 @@
+package handlers_test
+
+import (
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"net/http"
+	"testing"
+)
+
+func TestStartImpersonation(t *testing.T) {
+	tests := []struct {
+		name       string
+		callerType string
+		targetType string
+		wantStatus int
+		wantError  string
+	}{
+		{"admin as jobseeker", models.UserTypeAdmin, models.UserTypeJobSeeker, http.StatusOK, ""},
+		{"admin as organization", models.UserTypeAdmin, models.UserTypeOrganization, http.StatusOK, ""},
+		{"owner as jobseeker", models.UserTypeOwner, models.UserTypeJobSeeker, http.StatusOK, ""},
+		{"admin as viewer", models.UserTypeAdmin, models.UserTypeViewer, http.StatusForbidden, "user_not_impersonatable"},
+		{"admin as admin", models.UserTypeAdmin, models.UserTypeAdmin, http.StatusForbidden, "user_not_impersonatable"},
+		{"admin as owner", models.UserTypeAdmin, models.UserTypeOwner, http.StatusForbidden, "user_not_impersonatable"},
+		{"admin as service account", models.UserTypeAdmin, models.UserTypeService, http.StatusForbidden, "user_not_impersonatable"},
+		{"operator as jobseeker", models.UserTypeOperator, models.UserTypeJobSeeker, http.StatusForbidden, "forbidden"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := loginAs(t, createUser(t, tt.callerType))
+			target := createUser(t, tt.targetType)
+			expect(t, request(t, http.MethodPost, userPath(target.ID, "/impersonate"), token, nil), tt.wantStatus, tt.wantError)
+		})
+	}
+}
+
+func TestImpersonationTokenRestrictions(t *testing.T) {
+	admin := createUser(t, models.UserTypeAdmin)
+	adminToken := loginAs(t, admin)
+	target := createUser(t, models.UserTypeJobSeeker)
+	other := createUser(t, models.UserTypeJobSeeker)
+	token := impersonate(t, adminToken, target)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+	}{
+		{"change password", http.MethodPost, "/users/me/password", map[string]string{"current_password": testPassword, "new_password": "Battery-Staple-77", "confirm_password": "Battery-Staple-77"}},
+		{"revoke sessions", http.MethodDelete, "/users/me/sessions", nil},
+		{"set up 2FA", http.MethodPost, "/users/me/2fa/setup", nil},
+		{"list ownership transfers", http.MethodGet, "/ownership/transfers", nil},
+		{"delete a user", http.MethodDelete, userPath(other.ID, ""), nil},
+		{"anonymize a user", http.MethodPost, userPath(other.ID, "/anonymize"), nil},
+		{"impersonate again", http.MethodPost, userPath(other.ID, "/impersonate"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expect(t, request(t, tt.method, tt.path, token, tt.body), http.StatusForbidden, "impersonation_forbidden")
+		})
+	}
+
+	// Allowed routes act as the target
+	w := request(t, http.MethodGet, "/users/me/sessions", token, nil)
+	expect(t, w, http.StatusOK, "")
+	if sessions := decode(t, w).Data.([]any); len(sessions) == 0 || int(sessions[0].(map[string]any)["user_id"].(float64)) != target.ID {
+		t.Errorf("sessions = %v, want those of the target", sessions)
+	}
+}
+
+func TestImpersonationLifecycle(t *testing.T) {
+	admin := createUser(t, models.UserTypeAdmin)
+	adminToken := loginAs(t, admin)
+	target := createUser(t, models.UserTypeJobSeeker)
+
+	t.Run("stop", func(t *testing.T) {
+		token := impersonate(t, adminToken, target)
+		expect(t, request(t, http.MethodDelete, "/impersonation", adminToken, nil), http.StatusBadRequest, "not_impersonating")
+		expect(t, request(t, http.MethodDelete, "/impersonation", token, nil), http.StatusOK, "")
+		expect(t, request(t, http.MethodGet, "/users/me/sessions", token, nil), http.StatusUnauthorized, "session_revoked")
+
+		for _, eventType := range []string{models.SecurityEventImpersonationStarted, models.SecurityEventImpersonationStopped} {
+			found := false
+			for _, event := range storage.SecurityEvents().ListSecurityEvents(eventType, 1000) {
+				found = found || (event.ActorID == admin.ID && event.UserID == target.ID)
+			}
+			if !found {
+				t.Errorf("no %s event for admin %d and user %d", eventType, admin.ID, target.ID)
+			}
+		}
+	})
+
+	t.Run("admin loses access", func(t *testing.T) {
+		token := impersonate(t, adminToken, target)
+		demoted := admin
+		demoted.Type = models.UserTypeOperator
+		storage.UpdateUser(admin.ID, demoted)
+		defer storage.UpdateUser(admin.ID, admin)
+		expect(t, request(t, http.MethodGet, "/users/me/sessions", token, nil), http.StatusUnauthorized, "invalid_token")
+	})
+
+	t.Run("no refresh token", func(t *testing.T) {
+		w := request(t, http.MethodPost, userPath(target.ID, "/impersonate"), adminToken, nil)
+		expect(t, w, http.StatusOK, "")
+		if _, exists := decode(t, w).Data.(map[string]any)["refresh_token"]; exists {
+			t.Error("impersonation returned a refresh token")
+		}
+	})
+}
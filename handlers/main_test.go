@@ -0,0 +1,270 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hr-backend-system/mailer"
+	"hr-backend-system/models"
+	"hr-backend-system/routes"
+	"hr-backend-system/storage"
+	"hr-backend-system/utils"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testPassword is the password of every account made by createUser
+const testPassword = "Correct-Horse-42"
+
+var (
+	router *gin.Engine
+	outbox = &recordingMailer{}
+	serial atomic.Int64
+)
+
+func TestMain(m *testing.M) {
+	// Cheap hashes keep the tests fast. Rate limits and mandatory admin
+	// two-factor authentication are switched on by the tests that need them.
+	os.Setenv("ARGON2_MEMORY", "64")
+	os.Setenv("ARGON2_ITERATIONS", "1")
+	os.Setenv("RATE_LIMIT_ENABLED", "false")
+	os.Setenv("REQUIRE_ADMIN_TWO_FACTOR", "false")
+	exportDir, err := os.MkdirTemp("", "exports")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv("DATA_EXPORT_DIR", exportDir)
+
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+	mailer.SetDefault(outbox)
+
+	router = gin.New()
+	routes.SetupRoutes(router)
+	code := m.Run()
+	os.RemoveAll(exportDir)
+	os.Exit(code)
+}
+
+// recordingMailer keeps sent mail so tests can follow emailed links
+type recordingMailer struct {
+	mu       sync.Mutex
+	messages []mailer.Message
+	// gate, when set, holds every Send until it is closed, for at most
+	// a second
+	gate chan struct{}
+}
+
+func (m *recordingMailer) Send(msg mailer.Message) error {
+	m.mu.Lock()
+	gate := m.gate
+	m.mu.Unlock()
+	if gate != nil {
+		select {
+		case <-gate:
+		case <-time.After(time.Second):
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, msg)
+	return nil
+}
+
+// hold makes Send block until the returned function is called, which may
+// be called more than once
+func (m *recordingMailer) hold() (release func()) {
+	gate := make(chan struct{})
+	m.mu.Lock()
+	m.gate = gate
+	m.mu.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.mu.Lock()
+			m.gate = nil
+			m.mu.Unlock()
+			close(gate)
+		})
+	}
+}
+
+// lastTo returns the latest message sent to address, waiting briefly for
+// mail sent in the background
+func (m *recordingMailer) lastTo(t *testing.T, address string) mailer.Message {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		m.mu.Lock()
+		for i := len(m.messages) - 1; i >= 0; i-- {
+			if m.messages[i].To == address {
+				msg := m.messages[i]
+				m.mu.Unlock()
+				return msg
+			}
+		}
+		m.mu.Unlock()
+	}
+	t.Fatalf("no mail sent to %s", address)
+	return mailer.Message{}
+}
+
+// countTo returns how many messages were sent to address
+func (m *recordingMailer) countTo(address string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, msg := range m.messages {
+		if msg.To == address {
+			n++
+		}
+	}
+	return n
+}
+
+// tokenFromLink extracts the token query parameter of the link in a mail
+func tokenFromLink(t *testing.T, msg mailer.Message) string {
+	t.Helper()
+	_, rest, ok := strings.Cut(msg.Body, "token=")
+	if !ok {
+		t.Fatalf("no token in mail %q", msg.Body)
+	}
+	token, _, _ := strings.Cut(rest, "\n")
+	return strings.TrimSpace(token)
+}
+
+// createUser stores a verified account of userType with testPassword
+func createUser(t *testing.T, userType string) models.User {
+	t.Helper()
+	hash, err := utils.HashPassword(testPassword)
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+	n := serial.Add(1)
+	now := time.Now()
+	user := models.User{
+		ID:            storage.GetNextUserID(),
+		Name:          fmt.Sprintf("Test User %d", n),
+		Email:         fmt.Sprintf("user%d@example.com", n),
+		EmailVerified: true,
+		PhoneNumber:   fmt.Sprintf("+1555%07d", n),
+		Type:          userType,
+		Password:      hash,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	storage.AddUser(user)
+	return user
+}
+
+// loginAs opens a session for user and returns its access token
+func loginAs(t *testing.T, user models.User) string {
+	t.Helper()
+	sessionID, err := utils.GenerateID()
+	if err != nil {
+		t.Fatalf("GenerateID: %v", err)
+	}
+	now := time.Now()
+	storage.AddSession(models.Session{
+		ID:         sessionID,
+		UserID:     user.ID,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(time.Hour),
+	})
+	token, _, err := utils.GenerateAccessToken(user, sessionID)
+	if err != nil {
+		t.Fatalf("GenerateAccessToken: %v", err)
+	}
+	return token
+}
+
+// request sends a JSON request to the API, authenticated with token
+// unless it is empty
+func request(t *testing.T, method, path, token string, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	headers := map[string]string{}
+	if token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+	return send(t, method, path, body, headers)
+}
+
+// send sends a JSON request to the API with the given headers
+func send(t *testing.T, method, path string, body any, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, "/api/v1"+path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// decode parses an API response, failing the test on invalid JSON
+func decode(t *testing.T, w *httptest.ResponseRecorder) models.APIResponse {
+	t.Helper()
+	var response models.APIResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response %q: %v", w.Body.String(), err)
+	}
+	return response
+}
+
+// expect checks the status and error code of a response
+func expect(t *testing.T, w *httptest.ResponseRecorder, status int, errorCode string) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d: %s", w.Code, status, w.Body.String())
+	}
+	if got := decode(t, w).Error; got != errorCode {
+		t.Errorf("error = %q, want %q", got, errorCode)
+	}
+}
+
+// userPath returns the API path of a user, followed by suffix
+func userPath(id int, suffix string) string {
+	return fmt.Sprintf("/users/%d%s", id, suffix)
+}
+
+// reload returns the stored version of user
+func reload(t *testing.T, user models.User) models.User {
+	t.Helper()
+	stored, exists := storage.GetUserByID(user.ID)
+	if !exists {
+		t.Fatalf("user %d not found", user.ID)
+	}
+	return stored
+}
+
+// impersonate starts an impersonation of target with the admin's token and
+// returns the impersonation token
+func impersonate(t *testing.T, adminToken string, target models.User) string {
+	t.Helper()
+	w := request(t, http.MethodPost, userPath(target.ID, "/impersonate"), adminToken, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("impersonation refused: %s", w.Body.String())
+	}
+	data, _ := decode(t, w).Data.(map[string]any)
+	token, _ := data["access_token"].(string)
+	return token
+}
@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"errors"
+	"hr-backend-system/config"
+	"hr-backend-system/models"
+	"hr-backend-system/sso"
+	"hr-backend-system/storage"
+	"hr-backend-system/utils"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/oauth2"
+)
+
+// oidcStateCookie binds a provider callback to the browser that started the login
+const (
+	oidcStateCookie     = "oidc_state"
+	oidcStateCookiePath = "/api/v1/auth/oidc"
+)
+
+// GetOIDCProviders godoc
+// @Summary List login providers
+// @Description List the OpenID Connect providers users can log in with
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]models.OIDCProviderResponse}
+// @Router /auth/oidc/providers [get]
+func GetOIDCProviders(c *gin.Context) {
+	providers := sso.Providers()
+	result := make([]models.OIDCProviderResponse, len(providers))
+	for i, p := range providers {
+		result[i] = models.OIDCProviderResponse{
+			Name:        p.Name,
+			DisplayName: p.DisplayName,
+			LoginURL:    oidcStateCookiePath + "/" + p.Name + "/login",
+		}
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Login providers retrieved successfully",
+		Data:    result,
+	})
+}
+
+// OIDCLogin godoc
+// @Summary Start a provider login
+// @Description Redirect the browser to the identity provider. The login is protected with a state parameter, a nonce and PKCE.
+// @Tags auth
+// @Param provider path string true "Provider name"
+// @Success 302
+// @Failure 404 {object} models.APIResponse
+// @Failure 502 {object} models.APIResponse
+// @Router /auth/oidc/{provider}/login [get]
+func OIDCLogin(c *gin.Context) {
+	client, ok := getOIDCClient(c)
+	if !ok {
+		return
+	}
+
+	state, err := utils.GenerateRandomToken()
+	if err != nil {
+		oidcTokenError(c)
+		return
+	}
+	nonce, err := utils.GenerateRandomToken()
+	if err != nil {
+		oidcTokenError(c)
+		return
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	ttl := config.Get().OIDCStateTTL
+	storage.AddOIDCLoginState(models.OIDCLoginState{
+		StateHash:    utils.HashToken(state),
+		Provider:     c.Param("provider"),
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(ttl),
+	})
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, int(ttl.Seconds()), oidcStateCookiePath, "", config.Get().SecureCookies, true)
+	c.Redirect(http.StatusFound, client.AuthCodeURL(state, nonce, verifier))
+}
+
+// OIDCCallback godoc
+// @Summary Finish a provider login
+// @Description Handle the redirect back from the identity provider and log the user in. Accounts are linked by verified email address; unknown addresses get a new jobseeker account. Accounts with two-factor authentication receive a challenge token for /auth/2fa/verify instead.
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State from the login redirect"
+// @Success 200 {object} models.APIResponse{data=models.LoginResponse}
+// @Success 202 {object} models.APIResponse{data=models.TwoFactorChallengeResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 502 {object} models.APIResponse
+// @Router /auth/oidc/{provider}/callback [get]
+func OIDCCallback(c *gin.Context) {
+	if providerError := c.Query("error"); providerError != "" {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "The identity provider did not complete the login",
+			Error:   providerError,
+		})
+		return
+	}
+
+	// The state must match the cookie set by OIDCLogin so a login started
+	// by someone else cannot be completed in this browser
+	state := c.Query("state")
+	cookie, err := c.Cookie(oidcStateCookie)
+	c.SetCookie(oidcStateCookie, "", -1, oidcStateCookiePath, "", config.Get().SecureCookies, true)
+	if state == "" || err != nil || cookie != state {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid or expired login state",
+			Error:   "invalid_state",
+		})
+		return
+	}
+
+	loginState, exists := storage.ConsumeOIDCLoginState(utils.HashToken(state))
+	if !exists || loginState.Provider != c.Param("provider") {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid or expired login state",
+			Error:   "invalid_state",
+		})
+		return
+	}
+
+	client, ok := getOIDCClient(c)
+	if !ok {
+		return
+	}
+
+	identity, err := client.Exchange(c.Request.Context(), c.Query("code"), loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		log.Printf("oidc: login with %s failed: %v", loginState.Provider, err)
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Could not verify the login with the identity provider",
+			Error:   "oidc_exchange_failed",
+		})
+		return
+	}
+
+	user, ok := resolveOIDCUser(c, loginState.Provider, identity)
+	if !ok {
+		return
+	}
+
+	completeLogin(c, user, "")
+}
+
+// resolveOIDCUser returns the user a provider identity belongs to. Identities
+// seen before map to their linked user; otherwise the user is found by verified
+// email address and linked, or provisioned as a new jobseeker.
+func resolveOIDCUser(c *gin.Context, provider string, identity sso.Identity) (models.User, bool) {
+	if link, exists := storage.GetOIDCIdentity(provider, identity.Subject); exists {
+		if user, exists := storage.GetUserByID(link.UserID); exists {
+			return checkOIDCUser(c, user)
+		}
+		storage.DeleteOIDCIdentity(provider, identity.Subject)
+	}
+
+	// Only trust addresses the provider has verified, otherwise anyone could
+	// take over an account by registering its email at the provider
+	email := strings.ToLower(strings.TrimSpace(identity.Email))
+	if email == "" || !identity.EmailVerified {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "The identity provider has not verified your email address",
+			Error:   "email_not_verified",
+		})
+		return models.User{}, false
+	}
+
+	user, exists := storage.GetUserByEmail(email)
+	if exists {
+		if _, ok := checkOIDCUser(c, user); !ok {
+			return models.User{}, false
+		}
+		// The provider has just proven the user controls the address
+		if !user.EmailVerified {
+			before := user
+			user.EmailVerified = true
+			user.UpdatedAt = time.Now()
+			storage.UpdateUser(user.ID, user)
+			recordUserAudit(c, models.AuditUserEmailVerified, &before, &user)
+		}
+	} else {
+		name := strings.TrimSpace(identity.Name)
+		if name == "" {
+			name, _, _ = strings.Cut(email, "@")
+		}
+
+		// No password is set, the user can create one with forgot-password
+		user = models.User{
+			ID:            storage.GetNextUserID(),
+			Name:          name,
+			Email:         email,
+			EmailVerified: true,
+			Type:          models.UserTypeJobSeeker,
+			CreatedAt:     time.Now(),
+			UpdatedAt:     time.Now(),
+		}
+		storage.AddUser(user)
+		recordUserAudit(c, models.AuditUserRegistered, nil, &user)
+	}
+
+	storage.AddOIDCIdentity(models.OIDCIdentity{
+		Provider:  provider,
+		Subject:   identity.Subject,
+		UserID:    user.ID,
+		Email:     email,
+		CreatedAt: time.Now(),
+	})
+	return user, true
+}
+
+// checkOIDCUser rejects accounts that may not log in interactively
+func checkOIDCUser(c *gin.Context, user models.User) (models.User, bool) {
+	if user.IsService() {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "Service accounts cannot log in",
+			Error:   "forbidden",
+		})
+		return models.User{}, false
+	}
+	return user, true
+}
+
+// getOIDCClient loads the client for the :provider path parameter, writing
+// an error response and returning false if it is unavailable
+func getOIDCClient(c *gin.Context) (*sso.Client, bool) {
+	client, err := sso.Get(c.Request.Context(), c.Param("provider"))
+	if errors.Is(err, sso.ErrUnknownProvider) {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Login provider not found",
+			Error:   "provider_not_found",
+		})
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("oidc: %v", err)
+		c.JSON(http.StatusBadGateway, models.APIResponse{
+			Success: false,
+			Message: "Login provider is unavailable",
+			Error:   "provider_unavailable",
+		})
+		return nil, false
+	}
+	return client, true
+}
+
+func oidcTokenError(c *gin.Context) {
+	c.JSON(http.StatusInternalServerError, models.APIResponse{
+		Success: false,
+		Message: "Failed to start login",
+		Error:   "token_generation_error",
+	})
+}
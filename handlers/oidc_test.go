@@ -0,0 +1,221 @@
+package handlers_test
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"hr-backend-system/config"
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockProvider is an OpenID Connect provider that signs an ID token for
+// the identity set by the test
+type mockProvider struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu       sync.Mutex
+	identity map[string]any
+	nonce    string
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	p := &mockProvider{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                p.URL,
+			"authorization_endpoint":                p.URL + "/authorize",
+			"token_endpoint":                        p.URL + "/token",
+			"jwks_uri":                              p.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": "test",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		claims := jwt.MapClaims{
+			"iss":   p.URL,
+			"aud":   "hr-backend",
+			"iat":   time.Now().Unix(),
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"nonce": p.nonce,
+		}
+		for k, v := range p.identity {
+			claims[k] = v
+		}
+		p.mu.Unlock()
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "test"
+		signed, err := token.SignedString(key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"access_token": "access", "token_type": "Bearer", "id_token": signed})
+	})
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+// login starts a login with the provider, which will report identity, and
+// returns the state and the state cookie of the redirect
+func (p *mockProvider) login(t *testing.T, identity map[string]any) (string, *http.Cookie) {
+	t.Helper()
+	w := request(t, http.MethodGet, "/auth/oidc/mock/login", "", nil)
+	if w.Code != http.StatusFound {
+		t.Fatalf("login status = %d: %s", w.Code, w.Body.String())
+	}
+	location, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("invalid redirect: %v", err)
+	}
+	var cookie *http.Cookie
+	for _, c := range w.Result().Cookies() {
+		if c.Name == "oidc_state" {
+			cookie = c
+		}
+	}
+	if cookie == nil {
+		t.Fatal("no state cookie set")
+	}
+
+	p.mu.Lock()
+	p.identity = identity
+	p.nonce = location.Query().Get("nonce")
+	p.mu.Unlock()
+	return location.Query().Get("state"), cookie
+}
+
+// callback finishes a login as the provider's redirect would
+func callback(t *testing.T, state string, cookie *http.Cookie) *httptest.ResponseRecorder {
+	t.Helper()
+	headers := map[string]string{}
+	if cookie != nil {
+		headers["Cookie"] = cookie.Name + "=" + cookie.Value
+	}
+	return send(t, http.MethodGet, "/auth/oidc/mock/callback?code=code&state="+url.QueryEscape(state), nil, headers)
+}
+
+// loggedInUserID returns the ID of the user a login response is for
+func loggedInUserID(t *testing.T, w *httptest.ResponseRecorder) int {
+	t.Helper()
+	data, _ := decode(t, w).Data.(map[string]any)
+	user, _ := data["user"].(map[string]any)
+	id, _ := user["id"].(float64)
+	return int(id)
+}
+
+func TestOIDCLogin(t *testing.T) {
+	provider := newMockProvider(t)
+	cfg := config.Get()
+	providers := cfg.OIDCProviders
+	cfg.OIDCProviders = append(providers, config.OIDCProvider{
+		Name:         "mock",
+		IssuerURL:    provider.URL,
+		ClientID:     "hr-backend",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost/api/v1/auth/oidc/mock/callback",
+		Scopes:       []string{"openid", "email", "profile"},
+	})
+	defer func() { cfg.OIDCProviders = providers }()
+
+	viewer := createUser(t, models.UserTypeViewer)
+	admin := createUser(t, models.UserTypeAdmin)
+	service := createServiceAccount(t)
+	service.Email = "robot@example.com"
+	storage.UpdateUser(service.ID, service)
+	identity := func(subject, email string, verified bool) map[string]any {
+		return map[string]any{"sub": subject, "email": email, "email_verified": verified, "name": "Provider User"}
+	}
+
+	tests := []struct {
+		name       string
+		identity   map[string]any
+		wantStatus int
+		wantError  string
+		wantUserID int // 0 for a new account
+	}{
+		{"verified address of an account", identity("subject-viewer", viewer.Email, true), http.StatusOK, "", viewer.ID},
+		{"linked subject with another address", identity("subject-viewer", "changed@provider.example", true), http.StatusOK, "", viewer.ID},
+		{"unverified address of an account", identity("subject-attacker", admin.Email, false), http.StatusForbidden, "email_not_verified", 0},
+		{"service account address", identity("subject-robot", service.Email, true), http.StatusForbidden, "forbidden", 0},
+		{"unknown address", identity("subject-new", "newcomer@provider.example", true), http.StatusOK, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, cookie := provider.login(t, tt.identity)
+			w := callback(t, state, cookie)
+			expect(t, w, tt.wantStatus, tt.wantError)
+			if tt.wantStatus != http.StatusOK {
+				if _, linked := storage.GetOIDCIdentity("mock", tt.identity["sub"].(string)); linked {
+					t.Error("refused identity was linked")
+				}
+				return
+			}
+
+			id := loggedInUserID(t, w)
+			if tt.wantUserID != 0 && id != tt.wantUserID {
+				t.Errorf("logged in as %d, want %d", id, tt.wantUserID)
+			}
+			if tt.wantUserID == 0 {
+				user, _ := storage.GetUserByID(id)
+				if user.Type != models.UserTypeJobSeeker || user.Email != tt.identity["email"] || user.Password != "" {
+					t.Errorf("new account = %+v, want a jobseeker without a password", user)
+				}
+			}
+		})
+	}
+
+	t.Run("state checks", func(t *testing.T) {
+		state, cookie := provider.login(t, identity("subject-viewer", viewer.Email, true))
+		other, _ := provider.login(t, identity("subject-viewer", viewer.Email, true))
+		expect(t, callback(t, state, nil), http.StatusBadRequest, "invalid_state")
+		expect(t, callback(t, other, cookie), http.StatusBadRequest, "invalid_state")
+
+		state, cookie = provider.login(t, identity("subject-viewer", viewer.Email, true))
+		expect(t, callback(t, state, cookie), http.StatusOK, "")
+		expect(t, callback(t, state, cookie), http.StatusBadRequest, "invalid_state")
+	})
+
+	t.Run("nonce mismatch", func(t *testing.T) {
+		state, cookie := provider.login(t, identity("subject-viewer", viewer.Email, true))
+		provider.mu.Lock()
+		provider.nonce = "replayed"
+		provider.mu.Unlock()
+		expect(t, callback(t, state, cookie), http.StatusUnauthorized, "oidc_exchange_failed")
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		expect(t, request(t, http.MethodGet, "/auth/oidc/other/login", "", nil), http.StatusNotFound, "provider_not_found")
+	})
+}
@@ -0,0 +1,365 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"hr-backend-system/config"
+	"hr-backend-system/lockout"
+	"hr-backend-system/mailer"
+	"hr-backend-system/middleware"
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"hr-backend-system/utils"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetOwnershipTransfers godoc
+// @Summary List my ownership transfers
+// @Description Retrieve the ownership transfers the current user has offered or received
+// @Tags ownership
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]models.OwnershipTransfer}
+// @Failure 401 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /ownership/transfers [get]
+func GetOwnershipTransfers(c *gin.Context) {
+	user, ok := middleware.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Authentication required",
+			Error:   "unauthorized",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Ownership transfers retrieved successfully",
+		Data:    storage.GetUserOwnershipTransfers(user.ID),
+	})
+}
+
+// StartOwnershipTransfer godoc
+// @Summary Offer ownership to another account
+// @Description Start transferring the owner role to a staff account. The owner confirms with their password and, if enabled, a two-factor code. The recipient must accept before the transfer takes effect; the previous owner then becomes an admin. A new offer cancels any pending one.
+// @Tags ownership
+// @Accept json
+// @Produce json
+// @Param request body models.StartOwnershipTransferRequest true "Recipient and re-authentication"
+// @Success 201 {object} models.APIResponse{data=models.OwnershipTransfer}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 429 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /ownership/transfers [post]
+func StartOwnershipTransfer(c *gin.Context) {
+	var req models.StartOwnershipTransferRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	user, ok := middleware.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Authentication required",
+			Error:   "unauthorized",
+		})
+		return
+	}
+
+	if !user.IsOwner() {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "Only owners can transfer ownership",
+			Error:   "forbidden",
+		})
+		return
+	}
+
+	recipient, exists := storage.GetUserByID(req.RecipientID)
+	if !exists {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "User not found",
+			Error:   "user_not_found",
+		})
+		return
+	}
+
+	if recipient.ID == user.ID || recipient.IsOwner() || !recipient.IsStaff() {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Ownership can only be transferred to another staff account that is not an owner",
+			Error:   "invalid_recipient",
+		})
+		return
+	}
+
+	if !reauthenticate(c, &user, req.Password, req.Code) {
+		return
+	}
+
+	id, err := utils.GenerateID()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to create ownership transfer",
+			Error:   "token_generation_error",
+		})
+		return
+	}
+
+	now := time.Now()
+	transfer := models.OwnershipTransfer{
+		ID:         id,
+		FromUserID: user.ID,
+		ToUserID:   recipient.ID,
+		Status:     models.OwnershipTransferPending,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(config.Get().OwnershipTransferTTL),
+	}
+	storage.AddOwnershipTransfer(transfer)
+	sendOwnershipTransferEmail(user, recipient, transfer)
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Ownership transfer offered, waiting for the recipient to accept",
+		Data:    transfer,
+	})
+}
+
+// AcceptOwnershipTransfer godoc
+// @Summary Accept an ownership transfer
+// @Description Accept ownership offered to the current user. The recipient becomes an owner and the offering owner becomes an admin. The transfer is cancelled if the offering account is no longer an owner or the recipient is no longer a staff account.
+// @Tags ownership
+// @Accept json
+// @Produce json
+// @Param id path string true "Transfer ID"
+// @Success 200 {object} models.APIResponse{data=models.OwnershipTransfer}
+// @Failure 401 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /ownership/transfers/{id}/accept [post]
+func AcceptOwnershipTransfer(c *gin.Context) {
+	transfer, ok := getReceivedOwnershipTransfer(c)
+	if !ok {
+		return
+	}
+
+	transfer, closed := storage.CloseOwnershipTransfer(transfer.ID, models.OwnershipTransferAccepted)
+	if !closed {
+		ownershipTransferNotPending(c)
+		return
+	}
+
+	fromBefore, _ := storage.GetUserByID(transfer.FromUserID)
+	toBefore, _ := storage.GetUserByID(transfer.ToUserID)
+	from, to, err := storage.TransferOwnership(transfer.FromUserID, transfer.ToUserID)
+	if err != nil {
+		// One of the accounts was deleted or changed role since the offer
+		transfer.Status = models.OwnershipTransferCancelled
+		storage.UpdateOwnershipTransfer(transfer)
+		message := "The offering account can no longer transfer ownership"
+		if errors.Is(err, storage.ErrNotRecipient) {
+			message = "Ownership can only be transferred to a staff account that is not an owner"
+		}
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: message,
+			Error:   "ownership_transfer_invalid",
+		})
+		return
+	}
+	recordUserAudit(c, models.AuditUserOwnershipTransferred, &toBefore, &to)
+	recordUserAudit(c, models.AuditUserOwnershipTransferred, &fromBefore, &from)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Ownership transferred successfully",
+		Data:    transfer,
+	})
+}
+
+// DeclineOwnershipTransfer godoc
+// @Summary Decline an ownership transfer
+// @Description Decline ownership offered to the current user
+// @Tags ownership
+// @Accept json
+// @Produce json
+// @Param id path string true "Transfer ID"
+// @Success 200 {object} models.APIResponse{data=models.OwnershipTransfer}
+// @Failure 401 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /ownership/transfers/{id}/decline [post]
+func DeclineOwnershipTransfer(c *gin.Context) {
+	transfer, ok := getReceivedOwnershipTransfer(c)
+	if !ok {
+		return
+	}
+
+	transfer, closed := storage.CloseOwnershipTransfer(transfer.ID, models.OwnershipTransferDeclined)
+	if !closed {
+		ownershipTransferNotPending(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Ownership transfer declined",
+		Data:    transfer,
+	})
+}
+
+// CancelOwnershipTransfer godoc
+// @Summary Cancel an ownership transfer
+// @Description Withdraw a pending ownership transfer offered by the current user
+// @Tags ownership
+// @Accept json
+// @Produce json
+// @Param id path string true "Transfer ID"
+// @Success 200 {object} models.APIResponse{data=models.OwnershipTransfer}
+// @Failure 401 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /ownership/transfers/{id} [delete]
+func CancelOwnershipTransfer(c *gin.Context) {
+	user, ok := middleware.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Authentication required",
+			Error:   "unauthorized",
+		})
+		return
+	}
+
+	transfer, exists := storage.GetOwnershipTransfer(c.Param("id"))
+	if !exists || transfer.FromUserID != user.ID {
+		ownershipTransferNotFound(c)
+		return
+	}
+
+	transfer, closed := storage.CloseOwnershipTransfer(transfer.ID, models.OwnershipTransferCancelled)
+	if !closed {
+		ownershipTransferNotPending(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Ownership transfer cancelled",
+		Data:    transfer,
+	})
+}
+
+// getReceivedOwnershipTransfer loads the :id transfer if it was offered to
+// the current user, writing an error response and returning false otherwise
+func getReceivedOwnershipTransfer(c *gin.Context) (models.OwnershipTransfer, bool) {
+	user, ok := middleware.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Authentication required",
+			Error:   "unauthorized",
+		})
+		return models.OwnershipTransfer{}, false
+	}
+
+	transfer, exists := storage.GetOwnershipTransfer(c.Param("id"))
+	if !exists || transfer.ToUserID != user.ID {
+		ownershipTransferNotFound(c)
+		return models.OwnershipTransfer{}, false
+	}
+	return transfer, true
+}
+
+func ownershipTransferNotFound(c *gin.Context) {
+	c.JSON(http.StatusNotFound, models.APIResponse{
+		Success: false,
+		Message: "Ownership transfer not found",
+		Error:   "ownership_transfer_not_found",
+	})
+}
+
+func ownershipTransferNotPending(c *gin.Context) {
+	c.JSON(http.StatusConflict, models.APIResponse{
+		Success: false,
+		Message: "Ownership transfer is no longer pending",
+		Error:   "ownership_transfer_not_pending",
+	})
+}
+
+// reauthenticate confirms a sensitive action with the user's password and,
+// if two-factor authentication is enabled, a TOTP code. Failures count
+// towards the login lockout.
+func reauthenticate(c *gin.Context, user *models.User, password, code string) bool {
+	if !allowCredentialCheck(c, user.Email) {
+		return false
+	}
+
+	if !utils.CheckPassword(user.Password, password) {
+		lockout.RecordFailure(user.Email, c.ClientIP())
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Password is incorrect",
+			Error:   "invalid_password",
+		})
+		return false
+	}
+
+	if user.TwoFactorEnabled {
+		step, valid := utils.ValidateTOTP(user.TOTPSecret, code, user.TOTPLastStep)
+		if !valid {
+			lockout.RecordFailure(user.Email, c.ClientIP())
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Invalid two-factor code",
+				Error:   "invalid_two_factor_code",
+			})
+			return false
+		}
+		user.TOTPLastStep = step
+		storage.UpdateUser(user.ID, *user)
+	}
+
+	lockout.RecordSuccess(user.Email)
+	return true
+}
+
+// sendOwnershipTransferEmail tells the recipient about a transfer. Failures
+// are logged, the recipient can still find the offer in their transfer list.
+func sendOwnershipTransferEmail(from, to models.User, transfer models.OwnershipTransfer) {
+	cfg := config.Get()
+	link := strings.TrimRight(cfg.AppBaseURL, "/") + "/ownership-transfers/" + transfer.ID
+	err := mailer.Get().Send(mailer.Message{
+		To:      to.Email,
+		Subject: "You have been offered ownership",
+		Body: fmt.Sprintf("Hello %s,\n\n%s (%s) wants to make you the owner of the system. "+
+			"Log in and open the link below to accept or decline. The offer expires in %s.\n\n%s\n\n"+
+			"Once you accept, %s will become an admin.\n",
+			to.Name, from.Name, from.Email, cfg.OwnershipTransferTTL, link, from.Name),
+	})
+	if err != nil {
+		log.Printf("ownership transfer %s: %v", transfer.ID, err)
+	}
+}
@@ -0,0 +1,147 @@
+package handlers_test
+
+import (
+	"hr-backend-system/lockout"
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"net/http"
+	"testing"
+)
+
+func TestAcceptOwnershipTransferRechecksRecipient(t *testing.T) {
+	tests := []struct {
+		name       string
+		changeTo   string
+		wantStatus int
+		wantError  string
+	}{
+		{"still staff", "", http.StatusOK, ""},
+		{"demoted to an external account", models.UserTypeJobSeeker, http.StatusConflict, "ownership_transfer_invalid"},
+		{"became owner", models.UserTypeOwner, http.StatusConflict, "ownership_transfer_invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owner := createUser(t, models.UserTypeOwner)
+			recipient := createUser(t, models.UserTypeAdmin)
+			// A second owner keeps the last owner checks out of the way
+			createUser(t, models.UserTypeOwner)
+
+			w := request(t, http.MethodPost, "/ownership/transfers", loginAs(t, owner), map[string]any{
+				"recipient_id": recipient.ID,
+				"password":     testPassword,
+			})
+			expect(t, w, http.StatusCreated, "")
+			transferID := decode(t, w).Data.(map[string]any)["id"].(string)
+
+			if tt.changeTo != "" {
+				recipient.Type = tt.changeTo
+				storage.UpdateUser(recipient.ID, recipient)
+			}
+			w = request(t, http.MethodPost, "/ownership/transfers/"+transferID+"/accept", loginAs(t, recipient), nil)
+			expect(t, w, tt.wantStatus, tt.wantError)
+
+			wantOwner := tt.wantStatus == http.StatusOK
+			if got := reload(t, owner).Type == models.UserTypeOwner; got == wantOwner {
+				t.Errorf("offering account owner = %v, want %v", got, !wantOwner)
+			}
+			if tt.wantError != "" {
+				transfer, _ := storage.GetOwnershipTransfer(transferID)
+				if transfer.Status != models.OwnershipTransferCancelled {
+					t.Errorf("transfer status = %s, want cancelled", transfer.Status)
+				}
+			}
+		})
+	}
+}
+
+func TestStartOwnershipTransfer(t *testing.T) {
+	owner := createUser(t, models.UserTypeOwner)
+	ownerToken := loginAs(t, owner)
+	defer lockout.RecordSuccess(owner.Email)
+
+	tests := []struct {
+		name       string
+		caller     string
+		recipient  func() int
+		password   string
+		wantStatus int
+		wantError  string
+	}{
+		{"admin offers", models.UserTypeAdmin, func() int { return createUser(t, models.UserTypeViewer).ID }, testPassword, http.StatusForbidden, "forbidden"},
+		{"to itself", models.UserTypeOwner, func() int { return owner.ID }, testPassword, http.StatusBadRequest, "invalid_recipient"},
+		{"to another owner", models.UserTypeOwner, func() int { return createUser(t, models.UserTypeOwner).ID }, testPassword, http.StatusBadRequest, "invalid_recipient"},
+		{"to a jobseeker", models.UserTypeOwner, func() int { return createUser(t, models.UserTypeJobSeeker).ID }, testPassword, http.StatusBadRequest, "invalid_recipient"},
+		{"to a service account", models.UserTypeOwner, func() int { return createServiceAccount(t).ID }, testPassword, http.StatusBadRequest, "invalid_recipient"},
+		{"to a missing user", models.UserTypeOwner, func() int { return 999999 }, testPassword, http.StatusNotFound, "user_not_found"},
+		{"wrong password", models.UserTypeOwner, func() int { return createUser(t, models.UserTypeAdmin).ID }, "Wrong-Password-1", http.StatusBadRequest, "invalid_password"},
+		{"to an admin", models.UserTypeOwner, func() int { return createUser(t, models.UserTypeAdmin).ID }, testPassword, http.StatusCreated, ""},
+		{"to a viewer", models.UserTypeOwner, func() int { return createUser(t, models.UserTypeViewer).ID }, testPassword, http.StatusCreated, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := ownerToken
+			if tt.caller != models.UserTypeOwner {
+				token = loginAs(t, createUser(t, tt.caller))
+			}
+			w := request(t, http.MethodPost, "/ownership/transfers", token, map[string]any{
+				"recipient_id": tt.recipient(),
+				"password":     tt.password,
+			})
+			expect(t, w, tt.wantStatus, tt.wantError)
+		})
+	}
+}
+
+func TestOwnershipTransferParticipants(t *testing.T) {
+	owner := createUser(t, models.UserTypeOwner)
+	ownerToken := loginAs(t, owner)
+	offer := func(t *testing.T, recipient models.User) string {
+		t.Helper()
+		w := request(t, http.MethodPost, "/ownership/transfers", ownerToken, map[string]any{"recipient_id": recipient.ID, "password": testPassword})
+		expect(t, w, http.StatusCreated, "")
+		return decode(t, w).Data.(map[string]any)["id"].(string)
+	}
+	transferPath := func(id, action string) string { return "/ownership/transfers/" + id + action }
+
+	t.Run("only the recipient answers", func(t *testing.T) {
+		recipient := createUser(t, models.UserTypeAdmin)
+		id := offer(t, recipient)
+		bystander := loginAs(t, createUser(t, models.UserTypeAdmin))
+		for _, token := range []string{bystander, ownerToken} {
+			expect(t, request(t, http.MethodPost, transferPath(id, "/accept"), token, nil), http.StatusNotFound, "ownership_transfer_not_found")
+			expect(t, request(t, http.MethodPost, transferPath(id, "/decline"), token, nil), http.StatusNotFound, "ownership_transfer_not_found")
+		}
+		if reload(t, recipient).Type != models.UserTypeAdmin {
+			t.Error("recipient promoted")
+		}
+	})
+
+	t.Run("only the offering owner cancels", func(t *testing.T) {
+		recipient := createUser(t, models.UserTypeAdmin)
+		id := offer(t, recipient)
+		expect(t, request(t, http.MethodDelete, transferPath(id, ""), loginAs(t, recipient), nil), http.StatusNotFound, "ownership_transfer_not_found")
+		expect(t, request(t, http.MethodDelete, transferPath(id, ""), ownerToken, nil), http.StatusOK, "")
+		expect(t, request(t, http.MethodPost, transferPath(id, "/accept"), loginAs(t, recipient), nil), http.StatusConflict, "ownership_transfer_not_pending")
+	})
+
+	t.Run("declined offers cannot be accepted", func(t *testing.T) {
+		recipient := createUser(t, models.UserTypeAdmin)
+		id := offer(t, recipient)
+		token := loginAs(t, recipient)
+		expect(t, request(t, http.MethodPost, transferPath(id, "/decline"), token, nil), http.StatusOK, "")
+		expect(t, request(t, http.MethodPost, transferPath(id, "/accept"), token, nil), http.StatusConflict, "ownership_transfer_not_pending")
+		if reload(t, recipient).Type != models.UserTypeAdmin {
+			t.Error("recipient promoted")
+		}
+	})
+
+	t.Run("offering owner demoted meanwhile", func(t *testing.T) {
+		recipient := createUser(t, models.UserTypeAdmin)
+		id := offer(t, recipient)
+		demoted := owner
+		demoted.Type = models.UserTypeAdmin
+		storage.UpdateUser(owner.ID, demoted)
+		defer storage.UpdateUser(owner.ID, owner)
+		expect(t, request(t, http.MethodPost, transferPath(id, "/accept"), loginAs(t, recipient), nil), http.StatusConflict, "ownership_transfer_invalid")
+	})
+}
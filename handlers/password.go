@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"fmt"
+	"hr-backend-system/config"
+	"hr-backend-system/lockout"
+	"hr-backend-system/mailer"
+	"hr-backend-system/middleware"
+	"hr-backend-system/models"
+	"hr-backend-system/passwordpolicy"
+	"hr-backend-system/storage"
+	"hr-backend-system/utils"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ChangePassword godoc
+// @Summary Change the current user's password
+// @Description Verify the current password and set a new one. Recently used passwords are rejected and all other sessions are logged out.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body models.ChangePasswordRequest true "Change password request"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 429 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /users/me/password [post]
+func ChangePassword(c *gin.Context) {
+	var req models.ChangePasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	user, ok := middleware.GetCurrentUser(c)
+	claims, _ := middleware.GetClaims(c)
+	if !ok || claims == nil {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Authentication required",
+			Error:   "unauthorized",
+		})
+		return
+	}
+
+	if !allowCredentialCheck(c, user.Email) {
+		return
+	}
+
+	if !utils.CheckPassword(user.Password, req.CurrentPassword) {
+		lockout.RecordFailure(user.Email, c.ClientIP())
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Current password is incorrect",
+			Error:   "invalid_current_password",
+		})
+		return
+	}
+
+	if !checkPasswordPolicy(c, req.NewPassword, user.Name, user.Email, user.PendingEmail) {
+		return
+	}
+
+	// Reject the current password and recently used ones
+	if utils.CheckPassword(user.Password, req.NewPassword) || utils.PasswordInHistory(user.PasswordHistory, req.NewPassword) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "New password must not match a recently used password",
+			Error:   "password_reused",
+		})
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to process password",
+			Error:   "password_hash_error",
+		})
+		return
+	}
+
+	lockout.RecordSuccess(user.Email)
+	before := user
+	user.SetPassword(hashedPassword, config.Get().PasswordHistorySize)
+	user.UpdatedAt = time.Now()
+
+	storage.UpdateUser(user.ID, user)
+	recordUserAudit(c, models.AuditUserPasswordChanged, &before, &user)
+
+	// Keep the session that made the change, log out everywhere else
+	storage.RevokeOtherUserSessions(user.ID, claims.SessionID)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Password changed successfully",
+	})
+}
+
+// GetPasswordPolicy godoc
+// @Summary Get the password policy
+// @Description Retrieve the rules new passwords must follow, so clients can validate before submitting
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=passwordpolicy.Policy}
+// @Router /auth/password-policy [get]
+func GetPasswordPolicy(c *gin.Context) {
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Password policy retrieved successfully",
+		Data:    passwordpolicy.Current(),
+	})
+}
+
+// ForgotPassword godoc
+// @Summary Request a password reset
+// @Description Email a single-use password reset link. The response is the same whether or not the account exists.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.ForgotPasswordRequest true "Account email"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Router /auth/forgot-password [post]
+func ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	// Service accounts authenticate with API keys and never get a password.
+	// The mail is sent in the background so the response time does not
+	// reveal whether the account exists.
+	if user, exists := storage.GetUserByEmail(email); exists && !user.IsService() {
+		go func() {
+			if err := sendPasswordResetEmail(user); err != nil {
+				log.Printf("password reset for user %d: %v", user.ID, err)
+			}
+		}()
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "If an account with that email exists, a password reset link has been sent",
+	})
+}
+
+// ResetPassword godoc
+// @Summary Reset a password
+// @Description Set a new password using a reset token. The token can only be used once and all sessions are logged out.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.ResetPasswordRequest true "Reset password request"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Router /auth/reset-password [post]
+func ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	tokenHash := utils.HashToken(req.Token)
+	token, valid := storage.GetOneTimeToken(tokenHash, models.TokenPurposePasswordReset)
+	user, exists := storage.GetUserByID(token.UserID)
+	if !valid || !exists || user.IsService() {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid or expired reset token",
+			Error:   "invalid_reset_token",
+		})
+		return
+	}
+
+	// Check the new password before consuming so the user can retry with the same link
+	if !checkPasswordPolicy(c, req.NewPassword, user.Name, user.Email, user.PendingEmail) {
+		return
+	}
+
+	if utils.CheckPassword(user.Password, req.NewPassword) || utils.PasswordInHistory(user.PasswordHistory, req.NewPassword) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "New password must not match a recently used password",
+			Error:   "password_reused",
+		})
+		return
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to process password",
+			Error:   "password_hash_error",
+		})
+		return
+	}
+
+	if _, ok := storage.ConsumeOneTimeToken(tokenHash, models.TokenPurposePasswordReset); !ok {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid or expired reset token",
+			Error:   "invalid_reset_token",
+		})
+		return
+	}
+
+	before := user
+	user.SetPassword(hashedPassword, config.Get().PasswordHistorySize)
+	user.UpdatedAt = time.Now()
+
+	storage.UpdateUser(user.ID, user)
+	recordUserAudit(c, models.AuditUserPasswordReset, &before, &user)
+	storage.RevokeUserSessions(user.ID)
+	lockout.RecordSuccess(user.Email)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Password has been reset successfully",
+	})
+}
+
+// sendPasswordResetEmail issues a new reset token for the user and emails the reset link
+func sendPasswordResetEmail(user models.User) error {
+	cfg := config.Get()
+
+	token, err := utils.GenerateRandomToken()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	storage.AddOneTimeToken(models.OneTimeToken{
+		TokenHash: utils.HashToken(token),
+		Purpose:   models.TokenPurposePasswordReset,
+		UserID:    user.ID,
+		ExpiresAt: now.Add(cfg.PasswordResetTTL),
+		CreatedAt: now,
+	})
+
+	link := strings.TrimRight(cfg.AppBaseURL, "/") + "/reset-password?token=" + url.QueryEscape(token)
+	return mailer.Get().Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello %s,\n\nWe received a request to reset your password. "+
+			"Use the link below to choose a new one. The link expires in %s.\n\n%s\n\n"+
+			"If you did not request this, you can ignore this email.\n",
+			user.Name, cfg.PasswordResetTTL, link),
+	})
+}
+
+// checkPasswordPolicy validates a new password for the account with the
+// given name and emails, writing an error response and returning false if
+// the policy rejects it
+func checkPasswordPolicy(c *gin.Context, password, name string, emails ...string) bool {
+	err := passwordpolicy.Check(password, name, emails...)
+	if err == nil {
+		return true
+	}
+
+	code := "weak_password"
+	if violation, ok := passwordpolicy.AsViolation(err); ok {
+		code = violation.Code
+	}
+	c.JSON(http.StatusBadRequest, models.APIResponse{
+		Success: false,
+		Message: err.Error(),
+		Error:   code,
+	})
+	return false
+}
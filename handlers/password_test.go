@@ -0,0 +1,156 @@
+package handlers_test
+
+import (
+	"hr-backend-system/config"
+	"hr-backend-system/lockout"
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"hr-backend-system/utils"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestForgotPasswordRespondsBeforeMail(t *testing.T) {
+	user := createUser(t, models.UserTypeViewer)
+	release := outbox.hold()
+	defer release()
+
+	// A slow mail server must not make known accounts answer later
+	for _, email := range []string{user.Email, "nobody@example.com"} {
+		w := request(t, http.MethodPost, "/auth/forgot-password", "", map[string]string{"email": email})
+		expect(t, w, http.StatusOK, "")
+	}
+	if n := outbox.countTo(user.Email); n != 0 {
+		t.Fatalf("%d mails sent while the mailer was held", n)
+	}
+
+	release()
+	if msg := outbox.lastTo(t, user.Email); tokenFromLink(t, msg) == "" {
+		t.Error("reset mail has no token")
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	const newPassword = "Battery-Staple-77"
+	change := func(current, next string) map[string]string {
+		return map[string]string{"current_password": current, "new_password": next, "confirm_password": next}
+	}
+
+	user := createUser(t, models.UserTypeViewer)
+	token := loginAs(t, user)
+	other := loginAs(t, user)
+	defer lockout.RecordSuccess(user.Email)
+
+	tests := []struct {
+		name       string
+		body       map[string]string
+		wantStatus int
+		wantError  string
+	}{
+		{"wrong current password", change("Wrong-Password-1", newPassword), http.StatusBadRequest, "invalid_current_password"},
+		{"current password again", change(testPassword, testPassword), http.StatusBadRequest, "password_reused"},
+		{"contains the email", change(testPassword, user.Email), http.StatusBadRequest, "password_contains_personal_info"},
+		{"new password", change(testPassword, newPassword), http.StatusOK, ""},
+		{"back to a previous password", change(newPassword, testPassword), http.StatusBadRequest, "password_reused"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Keep the failed attempts below the progressive delay
+			lockout.RecordSuccess(user.Email)
+			expect(t, request(t, http.MethodPost, "/users/me/password", token, tt.body), tt.wantStatus, tt.wantError)
+		})
+	}
+
+	if !utils.CheckPassword(reload(t, user).Password, newPassword) {
+		t.Error("password not changed")
+	}
+	// Only the session that made the change stays logged in
+	expect(t, request(t, http.MethodGet, "/users/me/sessions", token, nil), http.StatusOK, "")
+	expect(t, request(t, http.MethodGet, "/users/me/sessions", other, nil), http.StatusUnauthorized, "session_revoked")
+}
+
+func TestChangePasswordLockout(t *testing.T) {
+	user := createUser(t, models.UserTypeViewer)
+	token := loginAs(t, user)
+	defer lockout.RecordSuccess(user.Email)
+
+	wrong := map[string]string{"current_password": "Wrong-Password-1", "new_password": "Battery-Staple-77", "confirm_password": "Battery-Staple-77"}
+	for i := 0; i < config.Get().LoginDelayAfter; i++ {
+		expect(t, request(t, http.MethodPost, "/users/me/password", token, wrong), http.StatusBadRequest, "invalid_current_password")
+	}
+	// Even the right password has to wait now
+	wrong["current_password"] = testPassword
+	expect(t, request(t, http.MethodPost, "/users/me/password", token, wrong), http.StatusTooManyRequests, lockout.ReasonTooManyAttempts)
+}
+
+func TestResetPassword(t *testing.T) {
+	const newPassword = "Battery-Staple-77"
+	reset := func(token, password string) map[string]string {
+		return map[string]string{"token": token, "new_password": password, "confirm_password": password}
+	}
+
+	user := createUser(t, models.UserTypeViewer)
+	session := loginAs(t, user)
+	// Only the latest link works
+	requestReset := func() string {
+		sent := outbox.countTo(user.Email)
+		expect(t, request(t, http.MethodPost, "/auth/forgot-password", "", map[string]string{"email": user.Email}), http.StatusOK, "")
+		for deadline := time.Now().Add(time.Second); outbox.countTo(user.Email) == sent && time.Now().Before(deadline); {
+			time.Sleep(5 * time.Millisecond)
+		}
+		return tokenFromLink(t, outbox.lastTo(t, user.Email))
+	}
+	superseded := requestReset()
+	token := requestReset()
+
+	// Tokens of other purposes or past their expiry are refused
+	expired, _ := utils.GenerateRandomToken()
+	storage.AddOneTimeToken(models.OneTimeToken{
+		TokenHash: utils.HashToken(expired),
+		Purpose:   models.TokenPurposePasswordReset,
+		UserID:    createUser(t, models.UserTypeViewer).ID,
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
+	verification, _ := utils.GenerateRandomToken()
+	storage.AddOneTimeToken(models.OneTimeToken{
+		TokenHash: utils.HashToken(verification),
+		Purpose:   models.TokenPurposeEmailVerification,
+		UserID:    user.ID,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+
+	tests := []struct {
+		name       string
+		body       map[string]string
+		wantStatus int
+		wantError  string
+	}{
+		{"unknown token", reset("not-a-token", newPassword), http.StatusBadRequest, "invalid_reset_token"},
+		{"expired token", reset(expired, newPassword), http.StatusBadRequest, "invalid_reset_token"},
+		{"superseded token", reset(superseded, newPassword), http.StatusBadRequest, "invalid_reset_token"},
+		{"verification token", reset(verification, newPassword), http.StatusBadRequest, "invalid_reset_token"},
+		{"current password", reset(token, testPassword), http.StatusBadRequest, "password_reused"},
+		{"new password", reset(token, newPassword), http.StatusOK, ""},
+		{"token used twice", reset(token, "Another-Password-88"), http.StatusBadRequest, "invalid_reset_token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expect(t, request(t, http.MethodPost, "/auth/reset-password", "", tt.body), tt.wantStatus, tt.wantError)
+		})
+	}
+
+	if !utils.CheckPassword(reload(t, user).Password, newPassword) {
+		t.Error("password not reset")
+	}
+	expect(t, request(t, http.MethodGet, "/users/me/sessions", session, nil), http.StatusUnauthorized, "session_revoked")
+}
+
+func TestForgotPasswordUnknownAccount(t *testing.T) {
+	const email = "unknown-account@example.com"
+	expect(t, request(t, http.MethodPost, "/auth/forgot-password", "", map[string]string{"email": email}), http.StatusOK, "")
+	time.Sleep(50 * time.Millisecond)
+	if n := outbox.countTo(email); n != 0 {
+		t.Errorf("%d mails sent to an unknown address", n)
+	}
+}
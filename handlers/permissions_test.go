@@ -0,0 +1,60 @@
+package handlers_test
+
+import (
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"net/http"
+	"testing"
+)
+
+func TestUpdateRole(t *testing.T) {
+	ownerToken := loginAs(t, createUser(t, models.UserTypeOwner))
+	adminToken := loginAs(t, createUser(t, models.UserTypeAdmin))
+	defer storage.SetRolePermissions(models.UserTypeViewer, models.DefaultRolePermissions[models.UserTypeViewer])
+
+	tests := []struct {
+		name        string
+		token       string
+		role        string
+		permissions []string
+		wantStatus  int
+		wantError   string
+	}{
+		{"admin edits a role", adminToken, models.UserTypeViewer, []string{models.PermUsersRead}, http.StatusForbidden, "forbidden"},
+		{"owner role", ownerToken, models.UserTypeOwner, []string{models.PermUsersRead}, http.StatusForbidden, "role_not_editable"},
+		{"unknown role", ownerToken, "superuser", []string{models.PermUsersRead}, http.StatusNotFound, "role_not_found"},
+		{"unknown permission", ownerToken, models.UserTypeViewer, []string{models.PermUsersRead, "users:everything"}, http.StatusBadRequest, "unknown_permission"},
+		{"owner edits a role", ownerToken, models.UserTypeViewer, []string{models.PermUsersRead, models.PermUsersRead, models.PermJobsRead}, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := request(t, http.MethodPut, "/roles/"+tt.role, tt.token, map[string]any{"permissions": tt.permissions})
+			expect(t, w, tt.wantStatus, tt.wantError)
+		})
+	}
+
+	role, _ := storage.GetRole(models.UserTypeViewer)
+	if len(role.Permissions) != 2 {
+		t.Errorf("viewer permissions = %v, want users:read and jobs:read once", role.Permissions)
+	}
+}
+
+func TestRolePermissionsApplyImmediately(t *testing.T) {
+	ownerToken := loginAs(t, createUser(t, models.UserTypeOwner))
+	token := loginAs(t, createUser(t, models.UserTypeOrganization))
+	defaults := models.DefaultRolePermissions[models.UserTypeOrganization]
+	defer storage.SetRolePermissions(models.UserTypeOrganization, defaults)
+
+	setPermissions := func(permissions []string) {
+		t.Helper()
+		w := request(t, http.MethodPut, "/roles/"+models.UserTypeOrganization, ownerToken, map[string]any{"permissions": permissions})
+		expect(t, w, http.StatusOK, "")
+	}
+
+	expect(t, request(t, http.MethodGet, "/users", token, nil), http.StatusForbidden, "forbidden")
+	// The same token gains and loses access with the role
+	setPermissions(append([]string{models.PermUsersRead}, defaults...))
+	expect(t, request(t, http.MethodGet, "/users", token, nil), http.StatusOK, "")
+	setPermissions(defaults)
+	expect(t, request(t, http.MethodGet, "/users", token, nil), http.StatusForbidden, "forbidden")
+}
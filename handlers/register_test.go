@@ -0,0 +1,62 @@
+package handlers_test
+
+import (
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	existing := createUser(t, models.UserTypeJobSeeker)
+	registration := func(email, userType, password string) map[string]string {
+		return map[string]string{
+			"name":         "New Applicant",
+			"email":        email,
+			"phone_number": "+15550001111",
+			"type":         userType,
+			"password":     password,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		body       map[string]string
+		wantStatus int
+		wantError  string // checked when set, binding errors carry a message
+	}{
+		{"jobseeker", registration("applicant@example.com", models.UserTypeJobSeeker, testPassword), http.StatusCreated, ""},
+		{"organization", registration("company@example.com", models.UserTypeOrganization, testPassword), http.StatusCreated, ""},
+		{"admin", registration("admin-signup@example.com", models.UserTypeAdmin, testPassword), http.StatusBadRequest, ""},
+		{"owner", registration("owner-signup@example.com", models.UserTypeOwner, testPassword), http.StatusBadRequest, ""},
+		{"service account", registration("service-signup@example.com", models.UserTypeService, testPassword), http.StatusBadRequest, ""},
+		{"duplicate email", registration(existing.Email, models.UserTypeJobSeeker, testPassword), http.StatusConflict, "duplicate_email"},
+		{"duplicate email in other case", registration(strings.ToUpper(existing.Email), models.UserTypeJobSeeker, testPassword), http.StatusConflict, "duplicate_email"},
+		{"short password", registration("short@example.com", models.UserTypeJobSeeker, "Ab1!"), http.StatusBadRequest, ""},
+		{"password with the email", registration("mallory@example.com", models.UserTypeJobSeeker, "mallory@example.com1"), http.StatusBadRequest, "password_contains_personal_info"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := request(t, http.MethodPost, "/auth/register", "", tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if got := decode(t, w).Error; tt.wantError != "" && got != tt.wantError {
+				t.Errorf("error = %q, want %q", got, tt.wantError)
+			}
+			if tt.wantStatus != http.StatusCreated {
+				if user, exists := storage.GetUserByEmail(tt.body["email"]); exists && user.ID != existing.ID {
+					t.Errorf("refused registration stored user %d", user.ID)
+				}
+				return
+			}
+
+			user, exists := storage.GetUserByEmail(tt.body["email"])
+			if !exists || user.Type != tt.body["type"] || user.EmailVerified {
+				t.Fatalf("stored user = %+v, want an unverified %s", user, tt.body["type"])
+			}
+			outbox.lastTo(t, user.Email)
+		})
+	}
+}
@@ -0,0 +1,461 @@
+package handlers
+
+import (
+	"hr-backend-system/audit"
+	"hr-backend-system/middleware"
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPermissions godoc
+// @Summary List permissions
+// @Description Retrieve the registry of permissions that can be granted to roles
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]models.Permission}
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /permissions [get]
+func GetPermissions(c *gin.Context) {
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Permissions retrieved successfully",
+		Data:    models.PermissionRegistry,
+	})
+}
+
+// GetRoles godoc
+// @Summary List roles
+// @Description Retrieve every role with the permissions it grants. Each user type has a role of the same name.
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]models.Role}
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /roles [get]
+func GetRoles(c *gin.Context) {
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Roles retrieved successfully",
+		Data:    storage.GetRoles(),
+	})
+}
+
+// GetRole godoc
+// @Summary Get a role
+// @Description Retrieve a role with the permissions it grants
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param name path string true "Role name"
+// @Success 200 {object} models.APIResponse{data=models.Role}
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /roles/{name} [get]
+func GetRole(c *gin.Context) {
+	role, exists := storage.GetRole(c.Param("name"))
+	if !exists {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Role not found",
+			Error:   "role_not_found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Role retrieved successfully",
+		Data:    role,
+	})
+}
+
+// UpdateRole godoc
+// @Summary Update a role's permissions
+// @Description Replace the permissions a role grants. Changes apply to all accounts of the role immediately. The owner role always has every permission and cannot be edited.
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param name path string true "Role name"
+// @Param role body models.UpdateRoleRequest true "Permissions of the role"
+// @Success 200 {object} models.APIResponse{data=models.Role}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /roles/{name} [put]
+func UpdateRole(c *gin.Context) {
+	var req models.UpdateRoleRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	role, exists := storage.GetRole(c.Param("name"))
+	if !exists {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Role not found",
+			Error:   "role_not_found",
+		})
+		return
+	}
+
+	if !role.Editable {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "This role cannot be edited",
+			Error:   "role_not_editable",
+		})
+		return
+	}
+
+	permissions := []string{}
+	seen := map[string]bool{}
+	for _, permission := range req.Permissions {
+		if !models.IsKnownPermission(permission) {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "Unknown permission: " + permission,
+				Error:   "unknown_permission",
+			})
+			return
+		}
+		if !seen[permission] {
+			seen[permission] = true
+			permissions = append(permissions, permission)
+		}
+	}
+
+	before := role.Permissions
+	role, _ = storage.SetRolePermissions(role.Name, permissions)
+	if changes := audit.RoleChanges(role.Name, before, role.Permissions); changes != nil {
+		recordAuditEntry(c, models.AuditRoleUpdated, 0, changes)
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Role updated successfully",
+		Data:    role,
+	})
+}
+
+// GetRoleChangeRequests godoc
+// @Summary List role change requests
+// @Description Retrieve requests to give external accounts a staff role, newest first
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param status query string false "Filter by status" Enums(pending, approved, rejected)
+// @Success 200 {object} models.APIResponse{data=[]models.RoleChangeRequest}
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /role-requests [get]
+func GetRoleChangeRequests(c *gin.Context) {
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Role change requests retrieved successfully",
+		Data:    storage.GetRoleChangeRequests(c.Query("status")),
+	})
+}
+
+// CreateRoleChangeRequest godoc
+// @Summary Request a staff role for an external account
+// @Description Ask an owner to convert a job seeker or organization account into a staff role. The change is applied when an owner approves it.
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param request body models.CreateRoleChangeRequest true "Account and requested role"
+// @Success 201 {object} models.APIResponse{data=models.RoleChangeRequest}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /role-requests [post]
+func CreateRoleChangeRequest(c *gin.Context) {
+	var req models.CreateRoleChangeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	currentUser, _ := middleware.GetCurrentUser(c)
+
+	user, exists := storage.GetUserByID(req.UserID)
+	if !exists {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "User not found",
+			Error:   "user_not_found",
+		})
+		return
+	}
+
+	if !models.IsExternalType(user.Type) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Only job seeker and organization accounts need owner approval, change staff roles directly",
+			Error:   "not_external_account",
+		})
+		return
+	}
+
+	if !models.CanAssignRole(middleware.GetCurrentRole(c), req.Type) {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "You can only assign roles below your own",
+			Error:   "role_not_assignable",
+		})
+		return
+	}
+
+	request := storage.AddRoleChangeRequest(models.RoleChangeRequest{
+		UserID:      user.ID,
+		FromType:    user.Type,
+		ToType:      req.Type,
+		Reason:      req.Reason,
+		Status:      models.RoleRequestPending,
+		RequestedBy: currentUser.ID,
+		CreatedAt:   time.Now(),
+	})
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Role change requested, waiting for owner approval",
+		Data:    request,
+	})
+}
+
+// ApproveRoleChangeRequest godoc
+// @Summary Approve a role change request
+// @Description Approve a pending request and give the account the requested staff role
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param id path int true "Request ID"
+// @Success 200 {object} models.APIResponse{data=models.RoleChangeRequest}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /role-requests/{id}/approve [post]
+func ApproveRoleChangeRequest(c *gin.Context) {
+	request, ok := getPendingRoleChangeRequest(c)
+	if !ok {
+		return
+	}
+
+	// The account may have changed since the request was made
+	user, exists := storage.GetUserByID(request.UserID)
+	if !exists || user.Type != request.FromType {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "The account has changed since the request was made",
+			Error:   "role_request_outdated",
+		})
+		return
+	}
+
+	if !checkRoleChange(c, user, request.ToType) {
+		return
+	}
+
+	currentUser, _ := middleware.GetCurrentUser(c)
+	request, reviewed := storage.ReviewRoleChangeRequest(request.ID, models.RoleRequestApproved, currentUser.ID)
+	if !reviewed {
+		roleRequestNotPending(c)
+		return
+	}
+
+	before := user
+	user.Type = request.ToType
+	user.UpdatedAt = time.Now()
+	storage.UpdateUser(user.ID, user)
+	recordUserAudit(c, models.AuditUserRoleChanged, &before, &user)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Role change approved",
+		Data:    request,
+	})
+}
+
+// RejectRoleChangeRequest godoc
+// @Summary Reject a role change request
+// @Description Reject a pending request, the account keeps its type
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param id path int true "Request ID"
+// @Success 200 {object} models.APIResponse{data=models.RoleChangeRequest}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /role-requests/{id}/reject [post]
+func RejectRoleChangeRequest(c *gin.Context) {
+	request, ok := getPendingRoleChangeRequest(c)
+	if !ok {
+		return
+	}
+
+	currentUser, _ := middleware.GetCurrentUser(c)
+	request, reviewed := storage.ReviewRoleChangeRequest(request.ID, models.RoleRequestRejected, currentUser.ID)
+	if !reviewed {
+		roleRequestNotPending(c)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Role change rejected",
+		Data:    request,
+	})
+}
+
+// checkRoleChange enforces the role hierarchy when the caller gives target
+// the type newType, writing an error response and returning false if the
+// change is not allowed
+func checkRoleChange(c *gin.Context, target models.User, newType string) bool {
+	currentUser, _ := middleware.GetCurrentUser(c)
+	callerRole := middleware.GetCurrentRole(c)
+
+	if target.ID == currentUser.ID {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "You cannot change your own role",
+			Error:   "cannot_change_own_role",
+		})
+		return false
+	}
+
+	// Service accounts authenticate with API keys only, a staff role would
+	// make them an interactive login without owner approval
+	if target.IsService() || newType == models.UserTypeService {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "Service accounts cannot change type, manage them through /service-accounts",
+			Error:   "service_account_restricted",
+		})
+		return false
+	}
+
+	// The owner role moves only through an ownership transfer, which
+	// guarantees an owner always exists
+	if newType == models.UserTypeOwner || target.IsOwner() {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "The owner role can only change by transferring ownership",
+			Error:   "ownership_transfer_required",
+		})
+		return false
+	}
+
+	// Both the account's current role and the new one must rank below the caller
+	if !models.CanAssignRole(callerRole, target.Type) || !models.CanAssignRole(callerRole, newType) {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "You can only manage accounts and assign roles below your own",
+			Error:   "role_not_assignable",
+		})
+		return false
+	}
+
+	if models.IsExternalType(target.Type) && models.IsStaffType(newType) && callerRole != models.UserTypeOwner {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "Giving an external account a staff role needs owner approval, create a role change request",
+			Error:   "owner_approval_required",
+		})
+		return false
+	}
+
+	return true
+}
+
+// checkManageUser writes an error response and returns false unless the
+// caller may edit or delete target. Staff accounts can only be managed by
+// callers with a higher role, so nobody can take over an account with more
+// privileges, e.g. by setting its password.
+func checkManageUser(c *gin.Context, target models.User) bool {
+	currentUser, _ := middleware.GetCurrentUser(c)
+	if target.ID == currentUser.ID {
+		return true
+	}
+
+	if models.RoleRank(target.Type) >= models.RoleRank(middleware.GetCurrentRole(c)) {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "You can only manage accounts below your own role",
+			Error:   "insufficient_role",
+		})
+		return false
+	}
+	return true
+}
+
+// getPendingRoleChangeRequest loads the :id request, writing an error
+// response and returning false if it does not exist or was reviewed
+func getPendingRoleChangeRequest(c *gin.Context) (models.RoleChangeRequest, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request ID",
+			Error:   "invalid_id",
+		})
+		return models.RoleChangeRequest{}, false
+	}
+
+	request, exists := storage.GetRoleChangeRequest(id)
+	if !exists {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Role change request not found",
+			Error:   "role_request_not_found",
+		})
+		return models.RoleChangeRequest{}, false
+	}
+
+	if request.Status != models.RoleRequestPending {
+		roleRequestNotPending(c)
+		return models.RoleChangeRequest{}, false
+	}
+	return request, true
+}
+
+func roleRequestNotPending(c *gin.Context) {
+	c.JSON(http.StatusConflict, models.APIResponse{
+		Success: false,
+		Message: "Role change request has already been reviewed",
+		Error:   "role_request_not_pending",
+	})
+}
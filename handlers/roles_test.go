@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"hr-backend-system/middleware"
+	"hr-backend-system/models"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// testContext returns a request context authenticated as caller
+func testContext(caller models.User) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPut, "/", nil)
+	c.Set(middleware.CurrentUserKey, caller)
+	return c, w
+}
+
+func responseError(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var response models.APIResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("invalid response %q: %v", w.Body.String(), err)
+	}
+	return response.Error
+}
+
+func TestCheckRoleChange(t *testing.T) {
+	owner := models.User{ID: 1, Type: models.UserTypeOwner}
+	admin := models.User{ID: 2, Type: models.UserTypeAdmin}
+	operator := models.User{ID: 3, Type: models.UserTypeOperator}
+
+	tests := []struct {
+		name      string
+		caller    models.User
+		target    models.User
+		newType   string
+		wantError string
+	}{
+		{"admin demotes operator", admin, models.User{ID: 10, Type: models.UserTypeOperator}, models.UserTypeViewer, ""},
+		{"own role", admin, admin, models.UserTypeViewer, "cannot_change_own_role"},
+		{"promote to owner", owner, models.User{ID: 10, Type: models.UserTypeAdmin}, models.UserTypeOwner, "ownership_transfer_required"},
+		{"demote owner", owner, models.User{ID: 10, Type: models.UserTypeOwner}, models.UserTypeAdmin, "ownership_transfer_required"},
+		{"assign own rank", admin, models.User{ID: 10, Type: models.UserTypeViewer}, models.UserTypeAdmin, "role_not_assignable"},
+		{"change higher account", operator, models.User{ID: 10, Type: models.UserTypeAdmin}, models.UserTypeViewer, "role_not_assignable"},
+		{"external to staff by admin", admin, models.User{ID: 10, Type: models.UserTypeJobSeeker}, models.UserTypeViewer, "owner_approval_required"},
+		{"external to staff by owner", owner, models.User{ID: 10, Type: models.UserTypeJobSeeker}, models.UserTypeViewer, ""},
+		{"external to external", operator, models.User{ID: 10, Type: models.UserTypeJobSeeker}, models.UserTypeOrganization, ""},
+		{"service account to staff", owner, models.User{ID: 10, Type: models.UserTypeService}, models.UserTypeViewer, "service_account_restricted"},
+		{"staff to service account", owner, models.User{ID: 10, Type: models.UserTypeViewer}, models.UserTypeService, "service_account_restricted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := testContext(tt.caller)
+			ok := checkRoleChange(c, tt.target, tt.newType)
+			if ok != (tt.wantError == "") {
+				t.Fatalf("checkRoleChange() = %v, want %v", ok, tt.wantError == "")
+			}
+			if !ok {
+				if w.Code != http.StatusForbidden {
+					t.Errorf("status = %d, want %d", w.Code, http.StatusForbidden)
+				}
+				if got := responseError(t, w); got != tt.wantError {
+					t.Errorf("error = %q, want %q", got, tt.wantError)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckManageUser(t *testing.T) {
+	admin := models.User{ID: 2, Type: models.UserTypeAdmin}
+
+	tests := []struct {
+		name   string
+		caller models.User
+		target models.User
+		want   bool
+	}{
+		{"self", admin, admin, true},
+		{"lower role", admin, models.User{ID: 10, Type: models.UserTypeOperator}, true},
+		{"external account", admin, models.User{ID: 10, Type: models.UserTypeJobSeeker}, true},
+		{"same role", admin, models.User{ID: 10, Type: models.UserTypeAdmin}, false},
+		{"owner", admin, models.User{ID: 10, Type: models.UserTypeOwner}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, w := testContext(tt.caller)
+			if got := checkManageUser(c, tt.target); got != tt.want {
+				t.Fatalf("checkManageUser() = %v, want %v", got, tt.want)
+			}
+			if !tt.want && responseError(t, w) != "insufficient_role" {
+				t.Errorf("error = %q, want insufficient_role", responseError(t, w))
+			}
+		})
+	}
+}
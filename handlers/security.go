@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"hr-backend-system/fieldcrypt"
+	"hr-backend-system/lockout"
+	"hr-backend-system/middleware"
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UnlockUser godoc
+// @Summary Unlock a user account
+// @Description Lift a brute-force lockout on a user account ranked below the caller and reset its failed attempts
+// @Tags security
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /users/{id}/unlock [post]
+func UnlockUser(c *gin.Context) {
+	user, ok := getUserFromParam(c)
+	if !ok || !checkManageUser(c, user) {
+		return
+	}
+
+	currentUser, _ := middleware.GetCurrentUser(c)
+	lockout.UnlockAccount(user.Email, currentUser.ID)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "User account unlocked successfully",
+	})
+}
+
+// UnlockIP godoc
+// @Summary Unlock an IP address
+// @Description Lift a brute-force lockout on a client IP address
+// @Tags security
+// @Accept json
+// @Produce json
+// @Param request body models.UnlockIPRequest true "IP address"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /security/unlock-ip [post]
+func UnlockIP(c *gin.Context) {
+	var req models.UnlockIPRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	currentUser, _ := middleware.GetCurrentUser(c)
+	lockout.UnlockIP(req.IP, currentUser.ID)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "IP address unlocked successfully",
+	})
+}
+
+// RotateEncryptionKey godoc
+// @Summary Rotate the encryption key
+// @Description Start encrypting personal data with a new key and re-encrypt the stored users with it. Values sealed with older keys, e.g. in the audit log, stay readable as long as those keys are kept.
+// @Tags security
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=models.KeyRotationResponse}
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 500 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /security/encryption-key/rotate [post]
+func RotateEncryptionKey(c *gin.Context) {
+	keyID, err := fieldcrypt.Rotate()
+	if err != nil {
+		log.Printf("encryption key rotation: %v", err)
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to rotate encryption key",
+			Error:   "internal_error",
+		})
+		return
+	}
+	reencrypted := storage.ReencryptUsers()
+
+	currentUser, _ := middleware.GetCurrentUser(c)
+	storage.SecurityEvents().AddSecurityEvent(models.SecurityEvent{
+		Type:    models.SecurityEventEncryptionKeyRotated,
+		IP:      c.ClientIP(),
+		ActorID: currentUser.ID,
+		Details: "key " + keyID,
+	})
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Encryption key rotated successfully",
+		Data: models.KeyRotationResponse{
+			KeyID:            keyID,
+			ReencryptedUsers: reencrypted,
+		},
+	})
+}
+
+// GetSecurityEvents godoc
+// @Summary List security events
+// @Description Retrieve recent security events such as lockouts, newest first
+// @Tags security
+// @Accept json
+// @Produce json
+// @Param type query string false "Event type" Enums(account_locked, ip_locked, account_unlocked, ip_unlocked, impersonation_started, impersonation_stopped, encryption_key_rotated)
+// @Param limit query int false "Maximum number of events" default(50)
+// @Success 200 {object} models.APIResponse{data=[]models.SecurityEvent}
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /security/events [get]
+func GetSecurityEvents(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 {
+		limit = 50
+	}
+	if limit > 500 {
+		limit = 500
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Security events retrieved successfully",
+		Data:    storage.SecurityEvents().ListSecurityEvents(c.Query("type"), limit),
+	})
+}
+
+// allowCredentialCheck applies brute-force protection before a password or
+// code is checked for email. When the attempt is refused it writes a 429
+// response with Retry-After and returns false.
+func allowCredentialCheck(c *gin.Context, email string) bool {
+	decision := lockout.Check(email, c.ClientIP())
+	if decision.Allowed {
+		return true
+	}
+
+	message := "Too many failed attempts, please try again later"
+	switch decision.Reason {
+	case lockout.ReasonAccountLocked:
+		message = "Account is temporarily locked due to too many failed attempts"
+	case lockout.ReasonIPLocked:
+		message = "Too many failed attempts from this address"
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(decision.RetryAfter.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, models.APIResponse{
+		Success: false,
+		Message: message,
+		Error:   decision.Reason,
+	})
+	return false
+}
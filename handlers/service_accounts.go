@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"hr-backend-system/middleware"
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"hr-backend-system/utils"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetServiceAccounts godoc
+// @Summary List service accounts
+// @Description Retrieve all service accounts used by integrations
+// @Tags service-accounts
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]models.UserResponse}
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /service-accounts [get]
+func GetServiceAccounts(c *gin.Context) {
+	accounts := []models.UserResponse{}
+	for _, user := range storage.GetAllUsers() {
+		if user.IsService() {
+			accounts = append(accounts, user.ToResponse())
+		}
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Service accounts retrieved successfully",
+		Data:    accounts,
+	})
+}
+
+// CreateServiceAccount godoc
+// @Summary Create a service account
+// @Description Create a non-human account for an integration. Service accounts cannot log in and authenticate with API keys only.
+// @Tags service-accounts
+// @Accept json
+// @Produce json
+// @Param account body models.CreateServiceAccountRequest true "Service account creation request"
+// @Success 201 {object} models.APIResponse{data=models.UserResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /service-accounts [post]
+func CreateServiceAccount(c *gin.Context) {
+	var req models.CreateServiceAccountRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	// No email or password, so the account can never log in
+	account := models.User{
+		ID:        storage.GetNextUserID(),
+		Name:      strings.TrimSpace(req.Name),
+		Type:      models.UserTypeService,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+
+	storage.AddUser(account)
+	recordUserAudit(c, models.AuditServiceAccountCreated, nil, &account)
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "Service account created successfully",
+		Data:    account.ToResponse(),
+	})
+}
+
+// GetAPIKeys godoc
+// @Summary List API keys of a service account
+// @Description Retrieve all API keys of a service account, including revoked ones. Key values are never returned.
+// @Tags service-accounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Service account ID"
+// @Success 200 {object} models.APIResponse{data=[]models.APIKey}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /service-accounts/{id}/keys [get]
+func GetAPIKeys(c *gin.Context) {
+	account, ok := getServiceAccount(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "API keys retrieved successfully",
+		Data:    storage.GetAPIKeysByUser(account.ID),
+	})
+}
+
+// CreateAPIKey godoc
+// @Summary Create an API key
+// @Description Issue a new API key for a service account. Send it in the X-API-Key header. The key is only shown once.
+// @Tags service-accounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Service account ID"
+// @Param key body models.CreateAPIKeyRequest true "API key creation request"
+// @Success 201 {object} models.APIResponse{data=models.APIKeyCreatedResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /service-accounts/{id}/keys [post]
+func CreateAPIKey(c *gin.Context) {
+	account, ok := getServiceAccount(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	plainKey, prefix, err := utils.GenerateAPIKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to generate API key",
+			Error:   "api_key_generation_error",
+		})
+		return
+	}
+
+	currentUser, _ := middleware.GetCurrentUser(c)
+	key := storage.AddAPIKey(models.APIKey{
+		UserID:    account.ID,
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    prefix,
+		KeyHash:   utils.HashToken(plainKey),
+		Scope:     req.Scope,
+		CreatedBy: currentUser.ID,
+		CreatedAt: time.Now(),
+	})
+
+	c.JSON(http.StatusCreated, models.APIResponse{
+		Success: true,
+		Message: "API key created successfully, store it now as it will not be shown again",
+		Data: models.APIKeyCreatedResponse{
+			Key:    plainKey,
+			APIKey: key,
+		},
+	})
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke an API key
+// @Description Revoke an API key of a service account. Revoked keys stop working immediately.
+// @Tags service-accounts
+// @Accept json
+// @Produce json
+// @Param id path int true "Service account ID"
+// @Param keyId path int true "API key ID"
+// @Success 200 {object} models.APIResponse{data=models.APIKey}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /service-accounts/{id}/keys/{keyId} [delete]
+func RevokeAPIKey(c *gin.Context) {
+	account, ok := getServiceAccount(c)
+	if !ok {
+		return
+	}
+
+	keyID, err := strconv.Atoi(c.Param("keyId"))
+	if err != nil || keyID < 1 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid API key ID",
+			Error:   "invalid_id",
+		})
+		return
+	}
+
+	key, exists := storage.RevokeAPIKey(account.ID, keyID)
+	if !exists {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "API key not found",
+			Error:   "api_key_not_found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "API key revoked successfully",
+		Data:    key,
+	})
+}
+
+// getServiceAccount loads the service account from the :id path parameter,
+// writing an error response and returning false if it does not exist
+func getServiceAccount(c *gin.Context) (models.User, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid service account ID",
+			Error:   "invalid_id",
+		})
+		return models.User{}, false
+	}
+
+	account, exists := storage.GetUserByID(id)
+	if !exists || !account.IsService() {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Service account not found",
+			Error:   "service_account_not_found",
+		})
+		return models.User{}, false
+	}
+	return account, true
+}
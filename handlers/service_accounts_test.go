@@ -0,0 +1,141 @@
+package handlers_test
+
+import (
+	"fmt"
+	"hr-backend-system/middleware"
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"hr-backend-system/utils"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// createServiceAccount stores a service account without a password, like
+// CreateServiceAccount does
+func createServiceAccount(t *testing.T) models.User {
+	t.Helper()
+	account := createUser(t, models.UserTypeService)
+	account.Password = ""
+	storage.UpdateUser(account.ID, account)
+	return account
+}
+
+func TestUpdateServiceAccount(t *testing.T) {
+	owner := createUser(t, models.UserTypeOwner)
+	token := loginAs(t, owner)
+
+	tests := []struct {
+		name       string
+		body       map[string]string
+		wantStatus int
+		wantError  string
+	}{
+		{"name", map[string]string{"name": "Payroll sync"}, http.StatusOK, ""},
+		{"email", map[string]string{"email": "attacker@example.com"}, http.StatusForbidden, "service_account_restricted"},
+		{"password", map[string]string{"password": "Another-Horse-43"}, http.StatusForbidden, "service_account_restricted"},
+		{"type", map[string]string{"type": models.UserTypeViewer}, http.StatusForbidden, "service_account_restricted"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account := createServiceAccount(t)
+			w := request(t, http.MethodPut, userPath(account.ID, ""), token, tt.body)
+			expect(t, w, tt.wantStatus, tt.wantError)
+
+			stored := reload(t, account)
+			if stored.Password != "" || stored.PendingEmail != "" || stored.Type != models.UserTypeService {
+				t.Errorf("service account changed to %+v", stored)
+			}
+		})
+	}
+}
+
+func TestServiceAccountPasswordReset(t *testing.T) {
+	account := createServiceAccount(t)
+
+	w := request(t, http.MethodPost, "/auth/forgot-password", "", map[string]string{"email": account.Email})
+	expect(t, w, http.StatusOK, "")
+	time.Sleep(50 * time.Millisecond)
+	if n := outbox.countTo(account.Email); n != 0 {
+		t.Errorf("%d reset mails sent to a service account, want none", n)
+	}
+
+	// A token issued before the account became a service account is refused
+	token := "service-reset-token"
+	storage.AddOneTimeToken(models.OneTimeToken{
+		TokenHash: utils.HashToken(token),
+		Purpose:   models.TokenPurposePasswordReset,
+		UserID:    account.ID,
+		ExpiresAt: time.Now().Add(time.Hour),
+		CreatedAt: time.Now(),
+	})
+	w = request(t, http.MethodPost, "/auth/reset-password", "", map[string]string{"token": token, "new_password": "Another-Horse-43", "confirm_password": "Another-Horse-43"})
+	expect(t, w, http.StatusBadRequest, "invalid_reset_token")
+	if stored := reload(t, account); stored.Password != "" {
+		t.Error("reset token set a password on a service account")
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	ownerToken := loginAs(t, createUser(t, models.UserTypeOwner))
+	w := request(t, http.MethodPost, "/service-accounts", ownerToken, map[string]string{"name": "Payroll sync"})
+	expect(t, w, http.StatusCreated, "")
+	accountID := int(decode(t, w).Data.(map[string]any)["id"].(float64))
+	keysPath := fmt.Sprintf("/service-accounts/%d/keys", accountID)
+
+	newKey := func(scope string) (string, int) {
+		w := request(t, http.MethodPost, keysPath, ownerToken, map[string]string{"name": scope + " key", "scope": scope})
+		expect(t, w, http.StatusCreated, "")
+		data := decode(t, w).Data.(map[string]any)
+		return data["key"].(string), int(data["api_key"].(map[string]any)["id"].(float64))
+	}
+	readKey, _ := newKey(models.APIKeyScopeRead)
+	writeKey, _ := newKey(models.APIKeyScopeWrite)
+	revokedKey, revokedID := newKey(models.APIKeyScopeWrite)
+	expect(t, request(t, http.MethodDelete, fmt.Sprintf("%s/%d", keysPath, revokedID), ownerToken, nil), http.StatusOK, "")
+
+	newUser := func(email string) map[string]string {
+		return map[string]string{"name": "Created By Key", "email": email, "type": models.UserTypeViewer, "password": testPassword}
+	}
+	tests := []struct {
+		name       string
+		key        string
+		method     string
+		path       string
+		body       any
+		wantStatus int
+		wantError  string
+	}{
+		{"read scope reads", readKey, http.MethodGet, "/users", nil, http.StatusOK, ""},
+		{"read scope writes", readKey, http.MethodPost, "/users", newUser("read-key@example.com"), http.StatusForbidden, "forbidden"},
+		{"write scope writes", writeKey, http.MethodPost, "/users", newUser("write-key@example.com"), http.StatusCreated, ""},
+		{"write scope manages keys", writeKey, http.MethodGet, keysPath, nil, http.StatusForbidden, "forbidden"},
+		{"two-factor routes", writeKey, http.MethodPost, "/users/me/2fa/setup", nil, http.StatusUnauthorized, "missing_token"},
+		{"revoked key", revokedKey, http.MethodGet, "/users", nil, http.StatusUnauthorized, "invalid_api_key"},
+		{"unknown key", "hrk_unknown", http.MethodGet, "/users", nil, http.StatusUnauthorized, "invalid_api_key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expect(t, send(t, tt.method, tt.path, tt.body, map[string]string{middleware.APIKeyHeader: tt.key}), tt.wantStatus, tt.wantError)
+		})
+	}
+
+	t.Run("listing hides key values", func(t *testing.T) {
+		w := request(t, http.MethodGet, keysPath, ownerToken, nil)
+		expect(t, w, http.StatusOK, "")
+		for _, key := range []string{readKey, writeKey, revokedKey} {
+			if strings.Contains(w.Body.String(), key) {
+				t.Errorf("key %s listed", key[:8])
+			}
+		}
+	})
+
+	t.Run("keys for other accounts", func(t *testing.T) {
+		viewer := createUser(t, models.UserTypeViewer)
+		w := request(t, http.MethodPost, fmt.Sprintf("/service-accounts/%d/keys", viewer.ID), ownerToken, map[string]string{"name": "key", "scope": "read"})
+		expect(t, w, http.StatusNotFound, "service_account_not_found")
+		w = request(t, http.MethodPost, keysPath, loginAs(t, viewer), map[string]string{"name": "key", "scope": "read"})
+		expect(t, w, http.StatusForbidden, "forbidden")
+	})
+}
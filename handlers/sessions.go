@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"hr-backend-system/middleware"
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetMySessions godoc
+// @Summary List my sessions
+// @Description Retrieve the active sessions of the current user with device, IP address and activity times
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=[]models.SessionResponse}
+// @Failure 401 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /users/me/sessions [get]
+func GetMySessions(c *gin.Context) {
+	user, ok := middleware.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Authentication required",
+			Error:   "unauthorized",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Sessions retrieved successfully",
+		Data:    sessionResponses(c, user.ID),
+	})
+}
+
+// RevokeMySession godoc
+// @Summary Revoke one of my sessions
+// @Description Log out a single session of the current user
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Param sessionId path string true "Session ID"
+// @Success 200 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /users/me/sessions/{sessionId} [delete]
+func RevokeMySession(c *gin.Context) {
+	user, ok := middleware.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Authentication required",
+			Error:   "unauthorized",
+		})
+		return
+	}
+
+	revokeSessionOf(c, user.ID, c.Param("sessionId"))
+}
+
+// RevokeMyOtherSessions godoc
+// @Summary Revoke my other sessions
+// @Description Log out every session of the current user except the one making the request
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /users/me/sessions [delete]
+func RevokeMyOtherSessions(c *gin.Context) {
+	user, ok := middleware.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Authentication required",
+			Error:   "unauthorized",
+		})
+		return
+	}
+
+	currentSessionID := ""
+	if claims, ok := middleware.GetClaims(c); ok {
+		currentSessionID = claims.SessionID
+	}
+	revoked := storage.RevokeOtherUserSessions(user.ID, currentSessionID)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Other sessions revoked successfully",
+		Data:    gin.H{"revoked": revoked},
+	})
+}
+
+// GetUserSessions godoc
+// @Summary List a user's sessions
+// @Description Retrieve the active sessions of a user ranked below the caller
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} models.APIResponse{data=[]models.SessionResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /users/{id}/sessions [get]
+func GetUserSessions(c *gin.Context) {
+	user, ok := getUserFromParam(c)
+	if !ok || !checkManageUser(c, user) {
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Sessions retrieved successfully",
+		Data:    sessionResponses(c, user.ID),
+	})
+}
+
+// RevokeUserSession godoc
+// @Summary Revoke a user's session
+// @Description Log out a single session of a user ranked below the caller
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param sessionId path string true "Session ID"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /users/{id}/sessions/{sessionId} [delete]
+func RevokeUserSession(c *gin.Context) {
+	user, ok := getUserFromParam(c)
+	if !ok || !checkManageUser(c, user) {
+		return
+	}
+
+	revokeSessionOf(c, user.ID, c.Param("sessionId"))
+}
+
+// RevokeAllUserSessions godoc
+// @Summary Revoke all of a user's sessions
+// @Description Log out every session of a user ranked below the caller
+// @Tags sessions
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /users/{id}/sessions [delete]
+func RevokeAllUserSessions(c *gin.Context) {
+	user, ok := getUserFromParam(c)
+	if !ok || !checkManageUser(c, user) {
+		return
+	}
+
+	revoked := storage.RevokeUserSessions(user.ID)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Sessions revoked successfully",
+		Data:    gin.H{"revoked": revoked},
+	})
+}
+
+// sessionResponses returns the active sessions of a user, flagging the
+// session the request was made with
+func sessionResponses(c *gin.Context, userID int) []models.SessionResponse {
+	currentSessionID := ""
+	if claims, ok := middleware.GetClaims(c); ok {
+		currentSessionID = claims.SessionID
+	}
+
+	sessions := storage.GetUserSessions(userID)
+	result := make([]models.SessionResponse, len(sessions))
+	for i, session := range sessions {
+		result[i] = models.SessionResponse{
+			Session: session,
+			Current: session.ID == currentSessionID,
+		}
+	}
+	return result
+}
+
+// revokeSessionOf revokes a session after checking it belongs to the user
+func revokeSessionOf(c *gin.Context, userID int, sessionID string) {
+	session, exists := storage.GetSession(sessionID)
+	if !exists || session.UserID != userID || !session.IsActive() {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "Session not found",
+			Error:   "session_not_found",
+		})
+		return
+	}
+
+	storage.RevokeSession(sessionID)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Session revoked successfully",
+	})
+}
+
+// getUserFromParam loads the user from the :id path parameter, writing an
+// error response and returning false if it does not exist
+func getUserFromParam(c *gin.Context) (models.User, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid user ID",
+			Error:   "invalid_id",
+		})
+		return models.User{}, false
+	}
+
+	user, exists := storage.GetUserByID(id)
+	if !exists {
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "User not found",
+			Error:   "user_not_found",
+		})
+		return models.User{}, false
+	}
+	return user, true
+}
@@ -0,0 +1,88 @@
+package handlers_test
+
+import (
+	"hr-backend-system/models"
+	"net/http"
+	"testing"
+)
+
+// currentSessionID returns the ID of the session token belongs to
+func currentSessionID(t *testing.T, token string) string {
+	t.Helper()
+	w := request(t, http.MethodGet, "/users/me/sessions", token, nil)
+	expect(t, w, http.StatusOK, "")
+	for _, session := range decode(t, w).Data.([]any) {
+		if session := session.(map[string]any); session["current"] == true {
+			return session["id"].(string)
+		}
+	}
+	t.Fatal("no current session listed")
+	return ""
+}
+
+func TestMySessions(t *testing.T) {
+	user := createUser(t, models.UserTypeViewer)
+	current, second, third := loginAs(t, user), loginAs(t, user), loginAs(t, user)
+	stranger := currentSessionID(t, loginAs(t, createUser(t, models.UserTypeViewer)))
+
+	w := request(t, http.MethodGet, "/users/me/sessions", current, nil)
+	expect(t, w, http.StatusOK, "")
+	if n := len(decode(t, w).Data.([]any)); n != 3 {
+		t.Fatalf("%d sessions listed, want 3", n)
+	}
+
+	expect(t, request(t, http.MethodDelete, "/users/me/sessions/"+stranger, current, nil), http.StatusNotFound, "session_not_found")
+	expect(t, request(t, http.MethodDelete, "/users/me/sessions/"+currentSessionID(t, second), current, nil), http.StatusOK, "")
+	expect(t, request(t, http.MethodGet, "/users/me/sessions", second, nil), http.StatusUnauthorized, "session_revoked")
+
+	expect(t, request(t, http.MethodDelete, "/users/me/sessions", current, nil), http.StatusOK, "")
+	expect(t, request(t, http.MethodGet, "/users/me/sessions", third, nil), http.StatusUnauthorized, "session_revoked")
+	expect(t, request(t, http.MethodGet, "/users/me/sessions", current, nil), http.StatusOK, "")
+}
+
+func TestUserSessionsAccess(t *testing.T) {
+	admin := createUser(t, models.UserTypeAdmin)
+	adminToken := loginAs(t, admin)
+
+	tests := []struct {
+		name       string
+		callerType string
+		targetType string
+		wantStatus int
+		wantError  string
+	}{
+		{"admin manages viewer", models.UserTypeAdmin, models.UserTypeViewer, http.StatusOK, ""},
+		{"admin manages jobseeker", models.UserTypeAdmin, models.UserTypeJobSeeker, http.StatusOK, ""},
+		{"admin manages admin", models.UserTypeAdmin, models.UserTypeAdmin, http.StatusForbidden, "insufficient_role"},
+		{"admin manages owner", models.UserTypeAdmin, models.UserTypeOwner, http.StatusForbidden, "insufficient_role"},
+		{"owner manages admin", models.UserTypeOwner, models.UserTypeAdmin, http.StatusOK, ""},
+		{"operator manages viewer", models.UserTypeOperator, models.UserTypeViewer, http.StatusForbidden, "forbidden"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := adminToken
+			if tt.callerType != models.UserTypeAdmin {
+				token = loginAs(t, createUser(t, tt.callerType))
+			}
+			target := createUser(t, tt.targetType)
+			targetToken := loginAs(t, target)
+			sessionID := currentSessionID(t, targetToken)
+
+			expect(t, request(t, http.MethodGet, userPath(target.ID, "/sessions"), token, nil), tt.wantStatus, tt.wantError)
+			expect(t, request(t, http.MethodDelete, userPath(target.ID, "/sessions/"+sessionID), token, nil), tt.wantStatus, tt.wantError)
+			expect(t, request(t, http.MethodDelete, userPath(target.ID, "/sessions"), token, nil), tt.wantStatus, tt.wantError)
+
+			wantStatus, wantError := http.StatusOK, ""
+			if tt.wantStatus == http.StatusOK {
+				wantStatus, wantError = http.StatusUnauthorized, "session_revoked"
+			}
+			expect(t, request(t, http.MethodGet, "/users/me/sessions", targetToken, nil), wantStatus, wantError)
+		})
+	}
+
+	t.Run("session of another user", func(t *testing.T) {
+		target := createUser(t, models.UserTypeViewer)
+		other := currentSessionID(t, loginAs(t, createUser(t, models.UserTypeViewer)))
+		expect(t, request(t, http.MethodDelete, userPath(target.ID, "/sessions/"+other), adminToken, nil), http.StatusNotFound, "session_not_found")
+	})
+}
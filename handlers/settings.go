@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"hr-backend-system/audit"
+	"hr-backend-system/middleware"
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSettings godoc
+// @Summary Get system settings
+// @Description Retrieve the system-wide policies
+// @Tags settings
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=models.Settings}
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /settings [get]
+func GetSettings(c *gin.Context) {
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Settings retrieved successfully",
+		Data:    storage.GetSettings(),
+	})
+}
+
+// UpdateSettings godoc
+// @Summary Update system settings
+// @Description Change system-wide policies, omitted fields are left unchanged. Only owners can change require_admin_two_factor, changes to it are recorded as security events.
+// @Tags settings
+// @Accept json
+// @Produce json
+// @Param settings body models.UpdateSettingsRequest true "Settings update request"
+// @Success 200 {object} models.APIResponse{data=models.Settings}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /settings [put]
+func UpdateSettings(c *gin.Context) {
+	var req models.UpdateSettingsRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	before := storage.GetSettings()
+	settings := before
+
+	// Admins could otherwise lift the policy and then turn off their own
+	// two-factor authentication
+	twoFactorChanged := req.RequireAdminTwoFactor != nil && *req.RequireAdminTwoFactor != before.RequireAdminTwoFactor
+	if twoFactorChanged && middleware.GetCurrentRole(c) != models.UserTypeOwner {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "Only owners can change require_admin_two_factor",
+			Error:   "owner_required",
+		})
+		return
+	}
+
+	if req.RequireEmailVerification != nil {
+		settings.RequireEmailVerification = *req.RequireEmailVerification
+	}
+	if req.RequireAdminTwoFactor != nil {
+		settings.RequireAdminTwoFactor = *req.RequireAdminTwoFactor
+	}
+	if req.UserDeletionMode != nil {
+		settings.UserDeletionMode = *req.UserDeletionMode
+	}
+	storage.UpdateSettings(settings)
+	if changes := audit.SettingsChanges(before, settings); len(changes) > 0 {
+		recordAuditEntry(c, models.AuditSettingsUpdated, 0, changes)
+	}
+	if twoFactorChanged {
+		currentUser, _ := middleware.GetCurrentUser(c)
+		details := "disabled"
+		if settings.RequireAdminTwoFactor {
+			details = "enabled"
+		}
+		storage.SecurityEvents().AddSecurityEvent(models.SecurityEvent{
+			Type:    models.SecurityEventAdminTwoFactorPolicyChanged,
+			IP:      c.ClientIP(),
+			ActorID: currentUser.ID,
+			Details: "mandatory admin two-factor authentication " + details,
+		})
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Settings updated successfully",
+		Data:    settings,
+	})
+}
@@ -0,0 +1,54 @@
+package handlers_test
+
+import (
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"net/http"
+	"testing"
+)
+
+func TestUpdateSettingsAdminTwoFactor(t *testing.T) {
+	owner := createUser(t, models.UserTypeOwner)
+	admin := createUser(t, models.UserTypeAdmin)
+	defer storage.UpdateSettings(storage.GetSettings())
+
+	tests := []struct {
+		name       string
+		caller     models.User
+		body       map[string]any
+		wantStatus int
+		wantError  string
+		wantEvent  bool
+	}{
+		{"admin lifts the policy", admin, map[string]any{"require_admin_two_factor": false}, http.StatusForbidden, "owner_required", false},
+		{"admin resends the current value", admin, map[string]any{"require_admin_two_factor": true}, http.StatusOK, "", false},
+		{"admin changes another setting", admin, map[string]any{"require_email_verification": false}, http.StatusOK, "", false},
+		{"owner lifts the policy", owner, map[string]any{"require_admin_two_factor": false}, http.StatusOK, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := storage.GetSettings()
+			settings.RequireAdminTwoFactor = true
+			storage.UpdateSettings(settings)
+			// Satisfy the policy so the callers are not sent to enrollment
+			for _, u := range []models.User{owner, admin} {
+				u = reload(t, u)
+				u.TwoFactorEnabled = true
+				storage.UpdateUser(u.ID, u)
+			}
+			events := len(storage.SecurityEvents().ListSecurityEvents(models.SecurityEventAdminTwoFactorPolicyChanged, 1000))
+
+			w := request(t, http.MethodPut, "/settings", loginAs(t, tt.caller), tt.body)
+			expect(t, w, tt.wantStatus, tt.wantError)
+
+			want := tt.wantStatus != http.StatusOK || !tt.wantEvent
+			if got := storage.GetSettings().RequireAdminTwoFactor; got != want {
+				t.Errorf("RequireAdminTwoFactor = %v, want %v", got, want)
+			}
+			logged := len(storage.SecurityEvents().ListSecurityEvents(models.SecurityEventAdminTwoFactorPolicyChanged, 1000)) > events
+			if logged != tt.wantEvent {
+				t.Errorf("security event recorded = %v, want %v", logged, tt.wantEvent)
+			}
+		})
+	}
+}
@@ -0,0 +1,502 @@
+package handlers
+
+import (
+	"hr-backend-system/config"
+	"hr-backend-system/lockout"
+	"hr-backend-system/middleware"
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"hr-backend-system/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	recoveryCodeCount          = 10
+	maxTwoFactorChallengeTries = 5
+)
+
+// SetupTwoFactor godoc
+// @Summary Start two-factor enrollment
+// @Description Generate a new TOTP secret with its otpauth URI and QR code. Two-factor authentication is enabled once a code is confirmed with /users/me/2fa/enable.
+// @Tags two-factor
+// @Accept json
+// @Produce json
+// @Success 200 {object} models.APIResponse{data=models.TwoFactorSetupResponse}
+// @Failure 401 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /users/me/2fa/setup [post]
+func SetupTwoFactor(c *gin.Context) {
+	user, ok := middleware.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Authentication required",
+			Error:   "unauthorized",
+		})
+		return
+	}
+
+	if user.TwoFactorEnabled {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "Two-factor authentication is already enabled",
+			Error:   "two_factor_already_enabled",
+		})
+		return
+	}
+
+	key, err := utils.GenerateTOTPKey(config.Get().TOTPIssuer, user.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to generate two-factor secret",
+			Error:   "two_factor_setup_error",
+		})
+		return
+	}
+
+	user.TOTPPendingSecret = key.Secret
+	user.UpdatedAt = time.Now()
+	storage.UpdateUser(user.ID, user)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Scan the QR code with your authenticator app and confirm a code to enable two-factor authentication",
+		Data: models.TwoFactorSetupResponse{
+			Secret:     key.Secret,
+			OTPAuthURL: key.OTPAuthURL,
+			QRCode:     key.QRCodePNG,
+		},
+	})
+}
+
+// EnableTwoFactor godoc
+// @Summary Confirm two-factor enrollment
+// @Description Verify a code from the authenticator app, enable two-factor authentication and return recovery codes. The recovery codes are only shown once.
+// @Tags two-factor
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} models.APIResponse{data=models.RecoveryCodesResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /users/me/2fa/enable [post]
+func EnableTwoFactor(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	user, ok := middleware.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Authentication required",
+			Error:   "unauthorized",
+		})
+		return
+	}
+
+	if user.TwoFactorEnabled {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "Two-factor authentication is already enabled",
+			Error:   "two_factor_already_enabled",
+		})
+		return
+	}
+
+	if user.TOTPPendingSecret == "" {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Start two-factor setup first",
+			Error:   "two_factor_setup_required",
+		})
+		return
+	}
+
+	step, valid := utils.ValidateTOTP(user.TOTPPendingSecret, req.Code, 0)
+	if !valid {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid two-factor code",
+			Error:   "invalid_two_factor_code",
+		})
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to generate recovery codes",
+			Error:   "two_factor_setup_error",
+		})
+		return
+	}
+
+	before := user
+	user.TwoFactorEnabled = true
+	user.TOTPSecret = user.TOTPPendingSecret
+	user.TOTPPendingSecret = ""
+	user.TOTPLastStep = step
+	user.RecoveryCodeHashes = hashes
+	user.UpdatedAt = time.Now()
+	storage.UpdateUser(user.ID, user)
+	recordUserAudit(c, models.AuditUserTwoFactorEnabled, &before, &user)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Two-factor authentication enabled, store the recovery codes in a safe place",
+		Data:    models.RecoveryCodesResponse{RecoveryCodes: codes},
+	})
+}
+
+// DisableTwoFactor godoc
+// @Summary Disable two-factor authentication
+// @Description Turn off two-factor authentication after confirming the password and a current code. Not allowed when the policy requires it for the account.
+// @Tags two-factor
+// @Accept json
+// @Produce json
+// @Param request body models.DisableTwoFactorRequest true "Password and TOTP code"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 429 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /users/me/2fa/disable [post]
+func DisableTwoFactor(c *gin.Context) {
+	var req models.DisableTwoFactorRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	user, ok := middleware.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Authentication required",
+			Error:   "unauthorized",
+		})
+		return
+	}
+
+	settings := storage.GetSettings()
+	if settings.TwoFactorRequiredFor(&user) {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "Two-factor authentication is mandatory for this account",
+			Error:   "two_factor_required",
+		})
+		return
+	}
+
+	if !user.TwoFactorEnabled {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Two-factor authentication is not enabled",
+			Error:   "two_factor_not_enabled",
+		})
+		return
+	}
+
+	if !allowCredentialCheck(c, user.Email) {
+		return
+	}
+
+	if !utils.CheckPassword(user.Password, req.Password) {
+		lockout.RecordFailure(user.Email, c.ClientIP())
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Password is incorrect",
+			Error:   "invalid_password",
+		})
+		return
+	}
+
+	if _, valid := utils.ValidateTOTP(user.TOTPSecret, req.Code, user.TOTPLastStep); !valid {
+		lockout.RecordFailure(user.Email, c.ClientIP())
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid two-factor code",
+			Error:   "invalid_two_factor_code",
+		})
+		return
+	}
+
+	before := user
+	user.TwoFactorEnabled = false
+	user.TOTPSecret = ""
+	user.TOTPPendingSecret = ""
+	user.TOTPLastStep = 0
+	user.RecoveryCodeHashes = nil
+	user.UpdatedAt = time.Now()
+	storage.UpdateUser(user.ID, user)
+	recordUserAudit(c, models.AuditUserTwoFactorDisabled, &before, &user)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Two-factor authentication disabled",
+	})
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replace all recovery codes after confirming a current TOTP code. The new codes are only shown once. Wrong codes count towards the login lockout.
+// @Tags two-factor
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorCodeRequest true "TOTP code"
+// @Success 200 {object} models.APIResponse{data=models.RecoveryCodesResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 429 {object} models.APIResponse
+// @Security BearerAuth
+// @Router /users/me/2fa/recovery-codes [post]
+func RegenerateRecoveryCodes(c *gin.Context) {
+	var req models.TwoFactorCodeRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	user, ok := middleware.GetCurrentUser(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Authentication required",
+			Error:   "unauthorized",
+		})
+		return
+	}
+
+	if !user.TwoFactorEnabled {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Two-factor authentication is not enabled",
+			Error:   "two_factor_not_enabled",
+		})
+		return
+	}
+
+	if !allowCredentialCheck(c, user.Email) {
+		return
+	}
+
+	step, valid := utils.ValidateTOTP(user.TOTPSecret, req.Code, user.TOTPLastStep)
+	if !valid {
+		lockout.RecordFailure(user.Email, c.ClientIP())
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid two-factor code",
+			Error:   "invalid_two_factor_code",
+		})
+		return
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to generate recovery codes",
+			Error:   "two_factor_setup_error",
+		})
+		return
+	}
+
+	before := user
+	user.TOTPLastStep = step
+	user.RecoveryCodeHashes = hashes
+	user.UpdatedAt = time.Now()
+	storage.UpdateUser(user.ID, user)
+	recordUserAudit(c, models.AuditUserRecoveryCodesReset, &before, &user)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Recovery codes regenerated, store them in a safe place",
+		Data:    models.RecoveryCodesResponse{RecoveryCodes: codes},
+	})
+}
+
+// VerifyTwoFactorLogin godoc
+// @Summary Complete a two-factor login
+// @Description Exchange the challenge token from /auth/login and a TOTP or recovery code for access and refresh tokens
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.TwoFactorLoginRequest true "Challenge token and code"
+// @Success 200 {object} models.APIResponse{data=models.LoginResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 429 {object} models.APIResponse
+// @Router /auth/2fa/verify [post]
+func VerifyTwoFactorLogin(c *gin.Context) {
+	var req models.TwoFactorLoginRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	if (req.Code == "") == (req.RecoveryCode == "") {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Provide either a two-factor code or a recovery code",
+			Error:   "missing_two_factor_code",
+		})
+		return
+	}
+
+	challengeHash := utils.HashToken(req.ChallengeToken)
+	challenge, valid := storage.GetOneTimeToken(challengeHash, models.TokenPurposeTwoFactorLogin)
+	user, exists := storage.GetUserByID(challenge.UserID)
+	if !valid || !exists || !user.TwoFactorEnabled {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Invalid or expired challenge, log in again",
+			Error:   "invalid_challenge_token",
+		})
+		return
+	}
+
+	if !allowCredentialCheck(c, user.Email) {
+		return
+	}
+
+	verified := false
+	if req.Code != "" {
+		if step, ok := utils.ValidateTOTP(user.TOTPSecret, req.Code, user.TOTPLastStep); ok {
+			user.TOTPLastStep = step
+			verified = true
+		}
+	} else {
+		verified = useRecoveryCode(&user, req.RecoveryCode)
+	}
+
+	if !verified {
+		// Limit guesses per challenge, the user has to log in again afterwards
+		storage.RecordOneTimeTokenFailure(challengeHash, maxTwoFactorChallengeTries)
+		lockout.RecordFailure(user.Email, c.ClientIP())
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Invalid two-factor code",
+			Error:   "invalid_two_factor_code",
+		})
+		return
+	}
+
+	if _, ok := storage.ConsumeOneTimeToken(challengeHash, models.TokenPurposeTwoFactorLogin); !ok {
+		c.JSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Invalid or expired challenge, log in again",
+			Error:   "invalid_challenge_token",
+		})
+		return
+	}
+
+	lockout.RecordSuccess(user.Email)
+	// Apply the hash upgrade from the first step, unless the password was
+	// changed since and no longer needs one
+	if challenge.Data != "" && utils.PasswordNeedsRehash(user.Password) {
+		replacePasswordHash(&user, challenge.Data)
+	}
+	user.UpdatedAt = time.Now()
+	storage.UpdateUser(user.ID, user)
+
+	tokens, err := createSession(c, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.APIResponse{
+			Success: false,
+			Message: "Failed to generate tokens",
+			Error:   "token_generation_error",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Login successful",
+		Data:    tokens,
+	})
+}
+
+// createTwoFactorChallenge issues the challenge token for the second login step
+// newPasswordHash, if set, replaces the stored hash once the challenge is
+// passed, see Login
+func createTwoFactorChallenge(user models.User, newPasswordHash string) (models.TwoFactorChallengeResponse, error) {
+	token, err := utils.GenerateRandomToken()
+	if err != nil {
+		return models.TwoFactorChallengeResponse{}, err
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(config.Get().TwoFactorChallengeTTL)
+	storage.AddOneTimeToken(models.OneTimeToken{
+		TokenHash: utils.HashToken(token),
+		Purpose:   models.TokenPurposeTwoFactorLogin,
+		UserID:    user.ID,
+		Data:      newPasswordHash,
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	})
+
+	return models.TwoFactorChallengeResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    token,
+		ExpiresAt:         expiresAt,
+	}, nil
+}
+
+// newRecoveryCodes generates recovery codes and the hashes to store for them
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = utils.HashToken(utils.NormalizeRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// useRecoveryCode removes a matching recovery code from the user, so each
+// code works only once. The caller must persist the user.
+func useRecoveryCode(user *models.User, code string) bool {
+	hash := utils.HashToken(utils.NormalizeRecoveryCode(code))
+	for i, stored := range user.RecoveryCodeHashes {
+		if stored == hash {
+			user.RecoveryCodeHashes = append(user.RecoveryCodeHashes[:i:i], user.RecoveryCodeHashes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package handlers_test
+
+import (
+	"hr-backend-system/config"
+	"hr-backend-system/lockout"
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"hr-backend-system/utils"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+)
+
+// enableTwoFactor turns on two-factor authentication for user and returns
+// the TOTP secret
+func enableTwoFactor(t *testing.T, user *models.User) string {
+	t.Helper()
+	key, err := utils.GenerateTOTPKey("test", user.Email)
+	if err != nil {
+		t.Fatalf("GenerateTOTPKey: %v", err)
+	}
+	user.TwoFactorEnabled = true
+	user.TOTPSecret = key.Secret
+	storage.UpdateUser(user.ID, *user)
+	return key.Secret
+}
+
+func TestRegenerateRecoveryCodesLockout(t *testing.T) {
+	user := createUser(t, models.UserTypeViewer)
+	secret := enableTwoFactor(t, &user)
+	token := loginAs(t, user)
+	defer lockout.RecordSuccess(user.Email)
+
+	// Wrong codes count as failed attempts, after LOGIN_DELAY_AFTER of
+	// them even a valid code has to wait
+	for i := 0; i < config.Get().LoginDelayAfter; i++ {
+		w := request(t, http.MethodPost, "/users/me/2fa/recovery-codes", token, map[string]string{"code": "000000"})
+		expect(t, w, http.StatusBadRequest, "invalid_two_factor_code")
+	}
+	code, err := totp.GenerateCode(secret, time.Now())
+	if err != nil {
+		t.Fatalf("GenerateCode: %v", err)
+	}
+	w := request(t, http.MethodPost, "/users/me/2fa/recovery-codes", token, map[string]string{"code": code})
+	expect(t, w, http.StatusTooManyRequests, lockout.ReasonTooManyAttempts)
+	if len(reload(t, user).RecoveryCodeHashes) != 0 {
+		t.Error("recovery codes regenerated while locked out")
+	}
+}
+
+func TestAdminTwoFactorEnforcement(t *testing.T) {
+	settings := storage.GetSettings()
+	defer storage.UpdateSettings(settings)
+	enforced := settings
+	enforced.RequireAdminTwoFactor = true
+	storage.UpdateSettings(enforced)
+
+	tests := []struct {
+		name       string
+		userType   string
+		twoFactor  bool
+		wantStatus int
+		wantError  string
+	}{
+		{"admin without 2FA", models.UserTypeAdmin, false, http.StatusForbidden, "two_factor_setup_required"},
+		{"owner without 2FA", models.UserTypeOwner, false, http.StatusForbidden, "two_factor_setup_required"},
+		{"admin with 2FA", models.UserTypeAdmin, true, http.StatusOK, ""},
+		{"operator without 2FA", models.UserTypeOperator, false, http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := createUser(t, tt.userType)
+			if tt.twoFactor {
+				enableTwoFactor(t, &user)
+			}
+			expect(t, request(t, http.MethodGet, "/users", loginAs(t, user), nil), tt.wantStatus, tt.wantError)
+		})
+	}
+}
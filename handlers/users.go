@@ -1,15 +1,20 @@
 package handlers
 
 import (
+	"errors"
+	"hr-backend-system/audit"
+	"hr-backend-system/config"
+	"hr-backend-system/dataexport"
+	"hr-backend-system/middleware"
 	"hr-backend-system/models"
 	"hr-backend-system/storage"
+	"hr-backend-system/utils"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 )
 
 // GetUsers godoc
@@ -22,6 +27,10 @@ import (
 // @Param limit query int false "Items per page" default(10)
 // @Success 200 {object} models.APIResponse
 // @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /users [get]
 func GetUsers(c *gin.Context) {
 	page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
@@ -95,14 +104,18 @@ func GetUsers(c *gin.Context) {
 
 // CreateUser godoc
 // @Summary Create a new user
-// @Description Create a new user with name and email
+// @Description Create a new user with name and email. Only roles below the caller's own can be assigned.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param user body models.CreateUserRequest true "User creation request"
 // @Success 201 {object} models.APIResponse
 // @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
 // @Failure 409 {object} models.APIResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /users [post]
 func CreateUser(c *gin.Context) {
 	var req models.CreateUserRequest
@@ -116,6 +129,26 @@ func CreateUser(c *gin.Context) {
 		return
 	}
 
+	// Owners are only made through an ownership transfer
+	if req.Type == models.UserTypeOwner {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "Owner accounts can only be created by transferring ownership",
+			Error:   "ownership_transfer_required",
+		})
+		return
+	}
+
+	// Callers can only create accounts with roles below their own
+	if !models.CanAssignRole(middleware.GetCurrentRole(c), req.Type) {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "You can only assign roles below your own",
+			Error:   "role_not_assignable",
+		})
+		return
+	}
+
 	// Validate required fields
 	if strings.TrimSpace(req.Name) == "" {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
@@ -144,8 +177,11 @@ func CreateUser(c *gin.Context) {
 		return
 	}
 
+	// Emails are stored lowercased
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
 	// Basic email validation
-	if !strings.Contains(req.Email, "@") {
+	if !strings.Contains(email, "@") {
 		c.JSON(http.StatusBadRequest, models.APIResponse{
 			Success: false,
 			Message: "Invalid email format",
@@ -155,7 +191,7 @@ func CreateUser(c *gin.Context) {
 	}
 
 	// Check if user already exists
-	if _, exists := storage.GetUserByEmail(req.Email); exists {
+	if _, exists := storage.GetUserByEmail(email); exists {
 		c.JSON(http.StatusConflict, models.APIResponse{
 			Success: false,
 			Message: "User with this email already exists",
@@ -164,8 +200,12 @@ func CreateUser(c *gin.Context) {
 		return
 	}
 
+	if !checkPasswordPolicy(c, req.Password, req.Name, email) {
+		return
+	}
+
 	// Hash password before storing
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := utils.HashPassword(req.Password)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.APIResponse{
 			Success: false,
@@ -178,14 +218,16 @@ func CreateUser(c *gin.Context) {
 	newUser := models.User{
 		ID:        storage.GetNextUserID(),
 		Name:      strings.TrimSpace(req.Name),
-		Email:     strings.ToLower(strings.TrimSpace(req.Email)),
+		Email:     email,
 		Type:      req.Type,
-		Password:  string(hashedPassword),
+		Password:  hashedPassword,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
 	storage.AddUser(newUser)
+	recordUserAudit(c, models.AuditUserCreated, nil, &newUser)
+	sendVerificationEmail(newUser, newUser.Email)
 
 	// Don't return password in response
 	newUser.Password = ""
@@ -206,7 +248,11 @@ func CreateUser(c *gin.Context) {
 // @Param id path int true "User ID"
 // @Success 200 {object} models.APIResponse
 // @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
 // @Failure 404 {object} models.APIResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /users/{id} [get]
 func GetUserByID(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -241,7 +287,7 @@ func GetUserByID(c *gin.Context) {
 
 // UpdateUser godoc
 // @Summary Update a user by ID
-// @Description Update a user's name or email by their ID
+// @Description Update a user's name, email, password or type by their ID. A new email only replaces the current one after it is verified. Your own password can only be changed with POST /users/me/password. The email and password of service accounts cannot be changed. Staff accounts can only be edited by higher roles, roles can only be assigned below the caller's own, and external accounts need an owner-approved role change request to become staff.
 // @Tags users
 // @Accept json
 // @Produce json
@@ -249,8 +295,12 @@ func GetUserByID(c *gin.Context) {
 // @Param user body models.UpdateUserRequest true "User update request"
 // @Success 200 {object} models.APIResponse
 // @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
 // @Failure 404 {object} models.APIResponse
 // @Failure 409 {object} models.APIResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /users/{id} [put]
 func UpdateUser(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -283,12 +333,52 @@ func UpdateUser(c *gin.Context) {
 		return
 	}
 
+	if !checkManageUser(c, user) {
+		return
+	}
+	if user.IsAnonymized() {
+		userAnonymized(c)
+		return
+	}
+	before := user
+
+	if _, impersonating := middleware.GetImpersonator(c); impersonating && req.Password != "" {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "This action is not allowed while impersonating a user",
+			Error:   "impersonation_forbidden",
+		})
+		return
+	}
+
+	// Users change their own password with the current one, see ChangePassword
+	if currentUser, _ := middleware.GetCurrentUser(c); req.Password != "" && user.ID == currentUser.ID {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "Change your own password with POST /users/me/password",
+			Error:   "use_password_change",
+		})
+		return
+	}
+
+	// Service accounts log in with API keys only. Their email cannot be
+	// changed either, the new inbox could request a password reset.
+	if user.IsService() && (req.Password != "" || req.Email != "") {
+		c.JSON(http.StatusForbidden, models.APIResponse{
+			Success: false,
+			Message: "Service accounts cannot have a password or change their email, they authenticate with API keys",
+			Error:   "service_account_restricted",
+		})
+		return
+	}
+
 	// Update name if provided
 	if req.Name != "" {
 		user.Name = strings.TrimSpace(req.Name)
 	}
 
 	// Update email if provided
+	emailChanged := false
 	if req.Email != "" {
 		email := strings.ToLower(strings.TrimSpace(req.Email))
 
@@ -312,12 +402,32 @@ func UpdateUser(c *gin.Context) {
 			})
 			return
 		}
-		user.Email = email
+
+		// Keep the current address active until the new one is verified
+		if email == user.Email {
+			user.PendingEmail = ""
+		} else if email != user.PendingEmail {
+			user.PendingEmail = email
+			emailChanged = true
+		}
 	}
 
-	// Update password if provided
+	// Update password if provided, checked against the updated name and addresses
 	if req.Password != "" {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		if !checkPasswordPolicy(c, req.Password, user.Name, user.Email, user.PendingEmail) {
+			return
+		}
+
+		if utils.CheckPassword(user.Password, req.Password) || utils.PasswordInHistory(user.PasswordHistory, req.Password) {
+			c.JSON(http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Message: "New password must not match a recently used password",
+				Error:   "password_reused",
+			})
+			return
+		}
+
+		hashedPassword, err := utils.HashPassword(req.Password)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, models.APIResponse{
 				Success: false,
@@ -326,17 +436,30 @@ func UpdateUser(c *gin.Context) {
 			})
 			return
 		}
-		user.Password = string(hashedPassword)
+		user.SetPassword(hashedPassword, config.Get().PasswordHistorySize)
 	}
 
-	// Update type if provided
-	if req.Type != "" {
+	// Update type if provided, role changes follow the role hierarchy
+	if req.Type != "" && req.Type != user.Type {
+		if !checkRoleChange(c, user, req.Type) {
+			return
+		}
 		user.Type = req.Type
 	}
 
 	user.UpdatedAt = time.Now()
 
 	storage.UpdateUser(id, user)
+	recordUserAudit(c, models.AuditUserUpdated, &before, &user)
+
+	// A new password logs the user out everywhere
+	if req.Password != "" {
+		storage.RevokeUserSessions(id)
+	}
+
+	if emailChanged {
+		sendVerificationEmail(user, user.PendingEmail)
+	}
 
 	// Don't return password in response
 	user.Password = ""
@@ -350,14 +473,19 @@ func UpdateUser(c *gin.Context) {
 
 // DeleteUser godoc
 // @Summary Delete a user by ID
-// @Description Delete a user from the system by ID
+// @Description Delete a user from the system by ID, together with their sessions, linked provider accounts and data exports; API keys are revoked and their personal data in the audit log is erased. When the user_deletion_mode setting is anonymize, the record is kept with its personal data scrubbed instead, see /users/{id}/anonymize. Staff accounts can only be deleted by higher roles and the last owner cannot be deleted.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param id path int true "User ID"
 // @Success 200 {object} models.APIResponse
 // @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
 // @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
 // @Router /users/{id} [delete]
 func DeleteUser(c *gin.Context) {
 	id, err := strconv.Atoi(c.Param("id"))
@@ -370,8 +498,35 @@ func DeleteUser(c *gin.Context) {
 		return
 	}
 
-	deletedUser, exists := storage.DeleteUser(id)
-	if !exists {
+	if user, exists := storage.GetUserByID(id); exists && !checkManageUser(c, user) {
+		return
+	}
+
+	if storage.GetSettings().UserDeletionMode == models.UserDeletionAnonymize {
+		anonymizedUser, ok := anonymizeUser(c, id)
+		if !ok {
+			return
+		}
+		c.JSON(http.StatusOK, models.APIResponse{
+			Success: true,
+			Message: "User anonymized successfully",
+			Data: gin.H{
+				"deleted_user": anonymizedUser.ToResponse(),
+			},
+		})
+		return
+	}
+
+	deletedUser, err := storage.DeleteUser(id)
+	if errors.Is(err, storage.ErrLastOwner) {
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "The last owner cannot be deleted, transfer ownership first",
+			Error:   "last_owner",
+		})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusNotFound, models.APIResponse{
 			Success: false,
 			Message: "User not found",
@@ -380,6 +535,9 @@ func DeleteUser(c *gin.Context) {
 		return
 	}
 
+	removeUserData(id)
+	recordUserAudit(c, models.AuditUserDeleted, &deletedUser, nil)
+
 	// Don't return password even for deleted user
 	deletedUser.Password = ""
 
@@ -391,3 +549,89 @@ func DeleteUser(c *gin.Context) {
 		},
 	})
 }
+
+// AnonymizeUser godoc
+// @Summary Anonymize a user
+// @Description Irreversibly replace the name, email, phone number and credentials of a user with placeholders, for erasure requests. The ID, type and timestamps are kept for reporting. Sessions, API keys, linked provider accounts and data exports of the user are removed and their personal data in the audit log is erased. Staff accounts can only be anonymized by higher roles and the last owner cannot be anonymized.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} models.APIResponse{data=models.UserResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 401 {object} models.APIResponse
+// @Failure 403 {object} models.APIResponse
+// @Failure 404 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Security BearerAuth
+// @Security APIKeyAuth
+// @Router /users/{id}/anonymize [post]
+func AnonymizeUser(c *gin.Context) {
+	user, ok := getUserFromParam(c)
+	if !ok || !checkManageUser(c, user) {
+		return
+	}
+
+	anonymizedUser, ok := anonymizeUser(c, user.ID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "User anonymized successfully",
+		Data:    anonymizedUser.ToResponse(),
+	})
+}
+
+// anonymizeUser scrubs a user's personal data and removes everything that
+// still ties the record to the person. The user's audit key is shredded
+// first, so the audit entry lists the erased fields without their values.
+func anonymizeUser(c *gin.Context, id int) (models.User, bool) {
+	before, after, err := storage.AnonymizeUser(id)
+	switch {
+	case errors.Is(err, storage.ErrLastOwner):
+		c.JSON(http.StatusConflict, models.APIResponse{
+			Success: false,
+			Message: "The last owner cannot be anonymized, transfer ownership first",
+			Error:   "last_owner",
+		})
+		return models.User{}, false
+	case errors.Is(err, storage.ErrAnonymized):
+		userAnonymized(c)
+		return models.User{}, false
+	case err != nil:
+		c.JSON(http.StatusNotFound, models.APIResponse{
+			Success: false,
+			Message: "User not found",
+			Error:   "user_not_found",
+		})
+		return models.User{}, false
+	}
+
+	removeUserData(id)
+	recordUserAudit(c, models.AuditUserAnonymized, &before, &after)
+	return after, true
+}
+
+// removeUserData removes what is stored about a user outside the user
+// record: sessions and refresh tokens, linked provider accounts, data
+// exports and their files. API keys are revoked so their use stays on record.
+// The user's personal data in the audit log becomes unreadable.
+func removeUserData(id int) {
+	audit.Shred(id)
+	storage.DeleteUserSessions(id)
+	storage.DeleteUserOIDCIdentities(id)
+	for _, key := range storage.GetAPIKeysByUser(id) {
+		storage.RevokeAPIKey(id, key.ID)
+	}
+	dataexport.RemoveUserExports(id)
+}
+
+func userAnonymized(c *gin.Context) {
+	c.JSON(http.StatusConflict, models.APIResponse{
+		Success: false,
+		Message: "User has been anonymized",
+		Error:   "user_anonymized",
+	})
+}
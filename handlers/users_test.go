@@ -0,0 +1,160 @@
+package handlers_test
+
+import (
+	"fmt"
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEraseUserAccess(t *testing.T) {
+	ownerToken := loginAs(t, createUser(t, models.UserTypeOwner))
+	adminToken := loginAs(t, createUser(t, models.UserTypeAdmin))
+	operatorToken := loginAs(t, createUser(t, models.UserTypeOperator))
+
+	tests := []struct {
+		name       string
+		token      string
+		targetType string
+		wantStatus int
+		wantError  string
+	}{
+		{"owner erases admin", ownerToken, models.UserTypeAdmin, http.StatusOK, ""},
+		{"admin erases viewer", adminToken, models.UserTypeViewer, http.StatusOK, ""},
+		{"admin erases external account", adminToken, models.UserTypeJobSeeker, http.StatusOK, ""},
+		{"admin erases owner", adminToken, models.UserTypeOwner, http.StatusForbidden, "insufficient_role"},
+		{"admin erases admin", adminToken, models.UserTypeAdmin, http.StatusForbidden, "insufficient_role"},
+		{"without delete permission", operatorToken, models.UserTypeViewer, http.StatusForbidden, "forbidden"},
+		{"without token", "", models.UserTypeViewer, http.StatusUnauthorized, "missing_token"},
+	}
+	for _, tt := range tests {
+		for _, route := range []struct{ method, suffix string }{{http.MethodDelete, ""}, {http.MethodPost, "/anonymize"}} {
+			t.Run(tt.name+" "+route.method, func(t *testing.T) {
+				target := createUser(t, tt.targetType)
+				expect(t, request(t, route.method, userPath(target.ID, route.suffix), tt.token, nil), tt.wantStatus, tt.wantError)
+				if _, exists := storage.GetUserByID(target.ID); tt.wantStatus != http.StatusOK && !exists {
+					t.Error("refused erasure removed the user")
+				}
+			})
+		}
+	}
+
+	t.Run("self", func(t *testing.T) {
+		user := createUser(t, models.UserTypeAdmin)
+		expect(t, request(t, http.MethodPost, userPath(user.ID, "/anonymize"), loginAs(t, user), nil), http.StatusOK, "")
+	})
+}
+
+func TestEraseUserRemovesData(t *testing.T) {
+	adminToken := loginAs(t, createUser(t, models.UserTypeAdmin))
+
+	tests := []struct {
+		name     string
+		method   string
+		suffix   string
+		mode     string
+		wantKept bool
+	}{
+		{"delete", http.MethodDelete, "", models.UserDeletionDelete, false},
+		{"delete in anonymize mode", http.MethodDelete, "", models.UserDeletionAnonymize, true},
+		{"anonymize", http.MethodPost, "/anonymize", models.UserDeletionDelete, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := storage.GetSettings()
+			defer storage.UpdateSettings(settings)
+			updated := settings
+			updated.UserDeletionMode = tt.mode
+			storage.UpdateSettings(updated)
+
+			user := createUser(t, models.UserTypeViewer)
+			token := loginAs(t, user)
+			key := storage.AddAPIKey(models.APIKey{UserID: user.ID, Name: "erased key", Scope: models.APIKeyScopeRead, CreatedAt: time.Now()})
+			startExport(t, token, user, models.DataExportFormatJSON)
+
+			expect(t, request(t, tt.method, userPath(user.ID, tt.suffix), adminToken, nil), http.StatusOK, "")
+
+			stored, kept := storage.GetUserByID(user.ID)
+			if kept != tt.wantKept {
+				t.Fatalf("record kept = %v, want %v", kept, tt.wantKept)
+			}
+			if kept && (!stored.IsAnonymized() || stored.Email == user.Email || stored.Password != "") {
+				t.Errorf("personal data kept: %+v", stored)
+			}
+			if sessions := storage.GetUserSessions(user.ID); len(sessions) != 0 {
+				t.Errorf("%d sessions left", len(sessions))
+			}
+			for _, k := range storage.GetAPIKeysByUser(user.ID) {
+				if k.ID == key.ID && k.IsActive() {
+					t.Error("API key still active")
+				}
+			}
+			if exports := storage.GetUserDataExports(user.ID); len(exports) != 0 {
+				t.Errorf("%d exports left", len(exports))
+			}
+
+			if w := request(t, http.MethodGet, userPath(user.ID, ""), token, nil); w.Code != http.StatusUnauthorized {
+				t.Errorf("old token status = %d, want %d", w.Code, http.StatusUnauthorized)
+			}
+			w := request(t, http.MethodPost, "/auth/login", "", map[string]string{"email": user.Email, "password": testPassword})
+			expect(t, w, http.StatusUnauthorized, "invalid_credentials")
+		})
+	}
+
+	t.Run("anonymize twice", func(t *testing.T) {
+		user := createUser(t, models.UserTypeViewer)
+		path := userPath(user.ID, "/anonymize")
+		expect(t, request(t, http.MethodPost, path, adminToken, nil), http.StatusOK, "")
+		expect(t, request(t, http.MethodPost, path, adminToken, nil), http.StatusConflict, "user_anonymized")
+	})
+}
+
+func TestErasureRemovesPersonalDataFromAudit(t *testing.T) {
+	owner := createUser(t, models.UserTypeOwner)
+	token := loginAs(t, owner)
+
+	tests := []struct {
+		name   string
+		method string
+		suffix string
+	}{
+		{"delete", http.MethodDelete, ""},
+		{"anonymize", http.MethodPost, "/anonymize"},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name := fmt.Sprintf("Erin Erasure%d", i)
+			email := fmt.Sprintf("erin.erasure%d@example.com", i)
+			w := request(t, http.MethodPost, "/users", token, map[string]string{
+				"name": name, "email": email, "type": models.UserTypeViewer, "password": "Distinct-Horse-77",
+			})
+			expect(t, w, http.StatusCreated, "")
+			created := decode(t, w).Data.(map[string]any)
+			id := int(created["id"].(float64))
+
+			renamed := name + " Jr"
+			expect(t, request(t, http.MethodPut, userPath(id, ""), token, map[string]string{"name": renamed}), http.StatusOK, "")
+
+			auditPath := fmt.Sprintf("/audit?target_id=%d", id)
+			if body := request(t, http.MethodGet, auditPath, token, nil).Body.String(); !strings.Contains(body, renamed) {
+				t.Fatalf("audit log before erasure lacks the name: %s", body)
+			}
+
+			expect(t, request(t, tt.method, userPath(id, tt.suffix), token, nil), http.StatusOK, "")
+
+			w = request(t, http.MethodGet, auditPath, token, nil)
+			expect(t, w, http.StatusOK, "")
+			for _, value := range []string{name, email, "erin.erasure"} {
+				if strings.Contains(w.Body.String(), value) {
+					t.Errorf("audit log still contains %q after erasure: %s", value, w.Body.String())
+				}
+			}
+			if !strings.Contains(w.Body.String(), `"redacted":true`) {
+				t.Errorf("audit log lists no redacted fields: %s", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"fmt"
+	"hr-backend-system/config"
+	"hr-backend-system/mailer"
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"hr-backend-system/utils"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// VerifyEmail godoc
+// @Summary Verify an email address
+// @Description Confirm an email address with the token from the verification email. For a pending email change the new address replaces the old one.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.VerifyEmailRequest true "Verification token"
+// @Success 200 {object} models.APIResponse{data=models.UserResponse}
+// @Failure 400 {object} models.APIResponse
+// @Failure 409 {object} models.APIResponse
+// @Router /auth/verify-email [post]
+func VerifyEmail(c *gin.Context) {
+	var req models.VerifyEmailRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	token, valid := storage.ConsumeOneTimeToken(utils.HashToken(req.Token), models.TokenPurposeEmailVerification)
+	user, exists := storage.GetUserByID(token.UserID)
+
+	// The token is only valid for the address it was sent to, and only
+	// while that address is still the account's current or pending email
+	if !valid || !exists || (token.Data != user.Email && token.Data != user.PendingEmail) {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid or expired verification token",
+			Error:   "invalid_verification_token",
+		})
+		return
+	}
+
+	before := user
+	if token.Data == user.PendingEmail {
+		// Another account may have taken the address since the change was requested
+		if existingUser, taken := storage.GetUserByEmail(token.Data); taken && existingUser.ID != user.ID {
+			c.JSON(http.StatusConflict, models.APIResponse{
+				Success: false,
+				Message: "Email already exists",
+				Error:   "duplicate_email",
+			})
+			return
+		}
+		user.Email = user.PendingEmail
+		user.PendingEmail = ""
+	}
+
+	user.EmailVerified = true
+	user.UpdatedAt = time.Now()
+	storage.UpdateUser(user.ID, user)
+	recordUserAudit(c, models.AuditUserEmailVerified, &before, &user)
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "Email verified successfully",
+		Data:    user.ToResponse(),
+	})
+}
+
+// ResendVerification godoc
+// @Summary Resend the verification email
+// @Description Send a new verification link for an unverified or pending email address. The response is the same whether or not the account exists.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.ResendVerificationRequest true "Account email"
+// @Success 200 {object} models.APIResponse
+// @Failure 400 {object} models.APIResponse
+// @Router /auth/resend-verification [post]
+func ResendVerification(c *gin.Context) {
+	var req models.ResendVerificationRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Message: "Invalid request data",
+			Error:   err.Error(),
+		})
+		return
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	// Sent in the background like password resets, so the response time
+	// does not reveal whether the account exists
+	if user, exists := storage.GetUserByEmail(email); exists {
+		switch {
+		case user.PendingEmail != "":
+			go sendVerificationEmail(user, user.PendingEmail)
+		case !user.EmailVerified:
+			go sendVerificationEmail(user, user.Email)
+		}
+	}
+
+	c.JSON(http.StatusOK, models.APIResponse{
+		Success: true,
+		Message: "If the email needs verification, a new link has been sent",
+	})
+}
+
+// sendVerificationEmail issues a verification token for email and sends
+// the link to that address. Failures are logged, not returned, so account
+// changes are not rolled back when mail delivery is down.
+func sendVerificationEmail(user models.User, email string) {
+	cfg := config.Get()
+
+	token, err := utils.GenerateRandomToken()
+	if err != nil {
+		log.Printf("email verification for user %d: %v", user.ID, err)
+		return
+	}
+
+	now := time.Now()
+	storage.AddOneTimeToken(models.OneTimeToken{
+		TokenHash: utils.HashToken(token),
+		Purpose:   models.TokenPurposeEmailVerification,
+		UserID:    user.ID,
+		Data:      email,
+		ExpiresAt: now.Add(cfg.EmailVerificationTTL),
+		CreatedAt: now,
+	})
+
+	link := strings.TrimRight(cfg.AppBaseURL, "/") + "/verify-email?token=" + url.QueryEscape(token)
+	err = mailer.Get().Send(mailer.Message{
+		To:      email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hello %s,\n\nPlease confirm %s as the email address for your account "+
+			"by opening the link below. The link expires in %s.\n\n%s\n\n"+
+			"If you did not expect this email, you can ignore it.\n",
+			user.Name, email, cfg.EmailVerificationTTL, link),
+	})
+	if err != nil {
+		log.Printf("email verification for user %d: %v", user.ID, err)
+	}
+}
@@ -0,0 +1,100 @@
+package handlers_test
+
+import (
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResendVerificationRespondsBeforeMail(t *testing.T) {
+	user := createUser(t, models.UserTypeViewer)
+	user.EmailVerified = false
+	storage.UpdateUser(user.ID, user)
+	release := outbox.hold()
+	defer release()
+
+	for _, email := range []string{user.Email, "nobody@example.com"} {
+		w := request(t, http.MethodPost, "/auth/resend-verification", "", map[string]string{"email": email})
+		expect(t, w, http.StatusOK, "")
+	}
+	if n := outbox.countTo(user.Email); n != 0 {
+		t.Fatalf("%d mails sent while the mailer was held", n)
+	}
+
+	release()
+	outbox.lastTo(t, user.Email)
+}
+
+func TestLoginRequiresVerifiedEmail(t *testing.T) {
+	settings := storage.GetSettings()
+	defer storage.UpdateSettings(settings)
+	required := settings
+	required.RequireEmailVerification = true
+	storage.UpdateSettings(required)
+
+	const email = "unverified@example.com"
+	w := request(t, http.MethodPost, "/auth/register", "", map[string]string{
+		"name":         "Unverified Applicant",
+		"email":        email,
+		"phone_number": "+15550002222",
+		"type":         models.UserTypeJobSeeker,
+		"password":     testPassword,
+	})
+	expect(t, w, http.StatusCreated, "")
+
+	login := map[string]string{"email": email, "password": testPassword}
+	expect(t, request(t, http.MethodPost, "/auth/login", "", login), http.StatusForbidden, "email_not_verified")
+
+	token := tokenFromLink(t, outbox.lastTo(t, email))
+	expect(t, request(t, http.MethodPost, "/auth/verify-email", "", map[string]string{"token": token}), http.StatusOK, "")
+	expect(t, request(t, http.MethodPost, "/auth/verify-email", "", map[string]string{"token": token}), http.StatusBadRequest, "invalid_verification_token")
+	expect(t, request(t, http.MethodPost, "/auth/login", "", login), http.StatusOK, "")
+}
+
+func TestEmailChangeVerification(t *testing.T) {
+	adminToken := loginAs(t, createUser(t, models.UserTypeAdmin))
+	changeEmail := func(t *testing.T, user models.User, email string) string {
+		t.Helper()
+		expect(t, request(t, http.MethodPut, userPath(user.ID, ""), adminToken, map[string]string{"email": email}), http.StatusOK, "")
+		return tokenFromLink(t, outbox.lastTo(t, email))
+	}
+	verify := func(token string) *httptest.ResponseRecorder {
+		return request(t, http.MethodPost, "/auth/verify-email", "", map[string]string{"token": token})
+	}
+
+	t.Run("new address", func(t *testing.T) {
+		user := createUser(t, models.UserTypeViewer)
+		token := changeEmail(t, user, "changed-address@example.com")
+		if stored := reload(t, user); stored.Email != user.Email || stored.PendingEmail != "changed-address@example.com" {
+			t.Fatalf("email = %q, pending %q; want the old address to stay until verified", stored.Email, stored.PendingEmail)
+		}
+		expect(t, verify(token), http.StatusOK, "")
+		if stored := reload(t, user); stored.Email != "changed-address@example.com" || stored.PendingEmail != "" {
+			t.Errorf("email = %q, pending %q after verification", stored.Email, stored.PendingEmail)
+		}
+	})
+
+	t.Run("replaced pending address", func(t *testing.T) {
+		user := createUser(t, models.UserTypeViewer)
+		first := changeEmail(t, user, "first-choice@example.com")
+		changeEmail(t, user, "second-choice@example.com")
+		expect(t, verify(first), http.StatusBadRequest, "invalid_verification_token")
+		if stored := reload(t, user); stored.Email != user.Email {
+			t.Errorf("email = %q, want it unchanged", stored.Email)
+		}
+	})
+
+	t.Run("address taken meanwhile", func(t *testing.T) {
+		user := createUser(t, models.UserTypeViewer)
+		token := changeEmail(t, user, "contested@example.com")
+		other := createUser(t, models.UserTypeViewer)
+		other.Email = "contested@example.com"
+		storage.UpdateUser(other.ID, other)
+		expect(t, verify(token), http.StatusConflict, "duplicate_email")
+		if stored := reload(t, user); stored.Email != user.Email {
+			t.Errorf("email = %q, want it unchanged", stored.Email)
+		}
+	})
+}
@@ -0,0 +1,134 @@
+package lockout
+
+import (
+	"fmt"
+	"hr-backend-system/config"
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"strings"
+	"time"
+)
+
+// Reasons a login attempt is refused
+const (
+	ReasonAccountLocked   = "account_locked"
+	ReasonIPLocked        = "ip_locked"
+	ReasonTooManyAttempts = "too_many_attempts"
+)
+
+// maxDelay caps the progressive delay between attempts
+const maxDelay = time.Minute
+
+// Decision is the result of Check
+type Decision struct {
+	Allowed    bool
+	Reason     string
+	RetryAfter time.Duration
+}
+
+// AccountKey returns the attempt key for an account. Unknown emails are
+// tracked the same way so lockouts do not reveal which accounts exist.
+func AccountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// IPKey returns the attempt key for a client IP address
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check reports whether a credential check for email from ip may proceed
+func Check(email, ip string) Decision {
+	store := storage.LoginAttempts()
+	now := time.Now()
+
+	if attempt, exists := store.GetLoginAttempt(IPKey(ip)); exists && attempt.IsLocked(now) {
+		return Decision{Reason: ReasonIPLocked, RetryAfter: attempt.LockedUntil.Sub(now)}
+	}
+
+	attempt, exists := store.GetLoginAttempt(AccountKey(email))
+	if !exists {
+		return Decision{Allowed: true}
+	}
+	if attempt.IsLocked(now) {
+		return Decision{Reason: ReasonAccountLocked, RetryAfter: attempt.LockedUntil.Sub(now)}
+	}
+	if next := attempt.LastFailureAt.Add(delayFor(attempt.Failures)); now.Before(next) {
+		return Decision{Reason: ReasonTooManyAttempts, RetryAfter: next.Sub(now)}
+	}
+	return Decision{Allowed: true}
+}
+
+// RecordFailure counts a failed credential check and locks the account or
+// IP address once its limit is reached
+func RecordFailure(email, ip string) {
+	cfg := config.Get()
+	store := storage.LoginAttempts()
+	now := time.Now()
+
+	account := store.RecordLoginFailure(AccountKey(email), cfg.LoginFailureWindow)
+	if account.Failures >= cfg.LoginMaxFailures && !account.IsLocked(now) {
+		store.LockLogin(account.Key, now.Add(cfg.LoginLockoutDuration))
+		storage.SecurityEvents().AddSecurityEvent(models.SecurityEvent{
+			Type:    models.SecurityEventAccountLocked,
+			Email:   strings.ToLower(strings.TrimSpace(email)),
+			IP:      ip,
+			Details: fmt.Sprintf("%d failed attempts, locked for %s", account.Failures, cfg.LoginLockoutDuration),
+		})
+	}
+
+	client := store.RecordLoginFailure(IPKey(ip), cfg.LoginFailureWindow)
+	if client.Failures >= cfg.LoginIPMaxFailures && !client.IsLocked(now) {
+		store.LockLogin(client.Key, now.Add(cfg.LoginLockoutDuration))
+		storage.SecurityEvents().AddSecurityEvent(models.SecurityEvent{
+			Type:    models.SecurityEventIPLocked,
+			IP:      ip,
+			Details: fmt.Sprintf("%d failed attempts, locked for %s", client.Failures, cfg.LoginLockoutDuration),
+		})
+	}
+}
+
+// RecordSuccess clears the failures of an account after a successful check
+func RecordSuccess(email string) {
+	store := storage.LoginAttempts()
+	if _, exists := store.GetLoginAttempt(AccountKey(email)); exists {
+		store.ClearLoginAttempts(AccountKey(email))
+	}
+}
+
+// UnlockAccount lifts a lockout on an account on behalf of an admin
+func UnlockAccount(email string, actorID int) {
+	storage.LoginAttempts().ClearLoginAttempts(AccountKey(email))
+	storage.SecurityEvents().AddSecurityEvent(models.SecurityEvent{
+		Type:    models.SecurityEventAccountUnlocked,
+		Email:   strings.ToLower(strings.TrimSpace(email)),
+		ActorID: actorID,
+	})
+}
+
+// UnlockIP lifts a lockout on an IP address on behalf of an admin
+func UnlockIP(ip string, actorID int) {
+	storage.LoginAttempts().ClearLoginAttempts(IPKey(ip))
+	storage.SecurityEvents().AddSecurityEvent(models.SecurityEvent{
+		Type:    models.SecurityEventIPUnlocked,
+		IP:      ip,
+		ActorID: actorID,
+	})
+}
+
+// delayFor returns how long to wait after the given number of consecutive
+// failures. The first few failures have no delay, then it doubles each time.
+func delayFor(failures int) time.Duration {
+	cfg := config.Get()
+	if failures < cfg.LoginDelayAfter {
+		return 0
+	}
+	delay := cfg.LoginDelayBase
+	for i := cfg.LoginDelayAfter; i < failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxDelay {
+		delay = maxDelay
+	}
+	return delay
+}
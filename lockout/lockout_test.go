@@ -0,0 +1,108 @@
+package lockout
+
+import (
+	"fmt"
+	"hr-backend-system/config"
+	"testing"
+	"time"
+)
+
+func TestDelayFor(t *testing.T) {
+	cfg := config.Get()
+	base := cfg.LoginDelayBase
+
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{cfg.LoginDelayAfter - 1, 0},
+		{cfg.LoginDelayAfter, base},
+		{cfg.LoginDelayAfter + 1, 2 * base},
+		{cfg.LoginDelayAfter + 2, 4 * base},
+		{cfg.LoginDelayAfter + 100, maxDelay},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d failures", tt.failures), func(t *testing.T) {
+			if got := delayFor(tt.failures); got != tt.want {
+				t.Errorf("delayFor(%d) = %s, want %s", tt.failures, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	cfg := config.Get()
+
+	tests := []struct {
+		name       string
+		email      string
+		ip         string
+		failures   int
+		success    bool
+		wantReason string
+	}{
+		{"no failures", "clean@example.com", "198.51.100.1", 0, false, ""},
+		{"below the delay", "few@example.com", "198.51.100.2", cfg.LoginDelayAfter - 1, false, ""},
+		{"delayed", "delayed@example.com", "198.51.100.3", cfg.LoginDelayAfter, false, ReasonTooManyAttempts},
+		{"locked", "locked@example.com", "198.51.100.4", cfg.LoginMaxFailures, false, ReasonAccountLocked},
+		{"email case ignored", "Mixed@Example.com", "198.51.100.5", cfg.LoginMaxFailures, false, ReasonAccountLocked},
+		{"cleared by success", "recovered@example.com", "198.51.100.6", cfg.LoginDelayAfter, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < tt.failures; i++ {
+				RecordFailure(tt.email, tt.ip)
+			}
+			if tt.success {
+				RecordSuccess(tt.email)
+			}
+
+			decision := Check(tt.email, tt.ip)
+			if decision.Reason != tt.wantReason || decision.Allowed != (tt.wantReason == "") {
+				t.Errorf("Check() = %+v, want reason %q", decision, tt.wantReason)
+			}
+			if !decision.Allowed && decision.RetryAfter <= 0 {
+				t.Errorf("RetryAfter = %s, want a positive duration", decision.RetryAfter)
+			}
+		})
+	}
+}
+
+func TestIPLockout(t *testing.T) {
+	cfg := config.Get()
+	ip := "203.0.113.99"
+
+	// Spread over many accounts so no single account locks first
+	for i := 0; i < cfg.LoginIPMaxFailures; i++ {
+		RecordFailure(fmt.Sprintf("spray%d@example.com", i), ip)
+	}
+
+	if decision := Check("someone-else@example.com", ip); decision.Reason != ReasonIPLocked {
+		t.Fatalf("Check() = %+v, want reason %q", decision, ReasonIPLocked)
+	}
+	if decision := Check("someone-else@example.com", "203.0.113.100"); !decision.Allowed {
+		t.Errorf("other IP refused: %+v", decision)
+	}
+
+	UnlockIP(ip, 1)
+	if decision := Check("someone-else@example.com", ip); !decision.Allowed {
+		t.Errorf("Check() after UnlockIP = %+v, want allowed", decision)
+	}
+}
+
+func TestUnlockAccount(t *testing.T) {
+	cfg := config.Get()
+	email := "unlock@example.com"
+	for i := 0; i < cfg.LoginMaxFailures; i++ {
+		RecordFailure(email, "192.0.2.10")
+	}
+	if decision := Check(email, "192.0.2.10"); decision.Reason != ReasonAccountLocked {
+		t.Fatalf("Check() = %+v, want reason %q", decision, ReasonAccountLocked)
+	}
+
+	UnlockAccount(email, 1)
+	if decision := Check(email, "192.0.2.10"); !decision.Allowed {
+		t.Errorf("Check() after UnlockAccount = %+v, want allowed", decision)
+	}
+}
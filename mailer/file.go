@@ -0,0 +1,35 @@
+package mailer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileMailer writes each message to a file in an outbox directory
+// instead of sending it, for local development and offline testing
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+// Send writes the message as an .eml file
+func (m *FileMailer) Send(msg Message) error {
+	if err := os.MkdirAll(m.Dir, 0o700); err != nil {
+		return fmt.Errorf("create outbox: %w", err)
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), hex.EncodeToString(suffix))
+
+	if err := os.WriteFile(filepath.Join(m.Dir, name), buildMessage(m.From, msg), 0o600); err != nil {
+		return fmt.Errorf("write outbox message: %w", err)
+	}
+	return nil
+}
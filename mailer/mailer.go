@@ -0,0 +1,57 @@
+package mailer
+
+import (
+	"hr-backend-system/config"
+	"log"
+	"sync"
+)
+
+// Message represents a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends email messages
+type Mailer interface {
+	Send(msg Message) error
+}
+
+var (
+	defaultMailer Mailer
+	once          sync.Once
+)
+
+// Get returns the mailer selected by the MAIL_DRIVER setting
+func Get() Mailer {
+	once.Do(func() {
+		defaultMailer = New(config.Get())
+	})
+	return defaultMailer
+}
+
+// SetDefault replaces the mailer returned by Get, e.g. to capture mail in tests
+func SetDefault(m Mailer) {
+	once.Do(func() {})
+	defaultMailer = m
+}
+
+// New creates a mailer from config, falling back to the file outbox
+func New(cfg *config.Config) Mailer {
+	switch cfg.MailDriver {
+	case "smtp":
+		return &SMTPMailer{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+		}
+	case "file", "":
+		return &FileMailer{Dir: cfg.MailOutboxDir, From: cfg.MailFrom}
+	default:
+		log.Printf("WARNING: unknown MAIL_DRIVER %q, writing mail to %s", cfg.MailDriver, cfg.MailOutboxDir)
+		return &FileMailer{Dir: cfg.MailOutboxDir, From: cfg.MailFrom}
+	}
+}
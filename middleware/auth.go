@@ -0,0 +1,257 @@
+package middleware
+
+import (
+	"hr-backend-system/models"
+	"hr-backend-system/storage"
+	"hr-backend-system/utils"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Context keys set by AuthRequired
+const (
+	CurrentUserKey  = "currentUser"
+	ClaimsKey       = "claims"
+	APIKeyKey       = "apiKey"
+	ImpersonatorKey = "impersonator"
+)
+
+// APIKeyHeader carries service account API keys
+const APIKeyHeader = "X-API-Key"
+
+// AuthRequired validates the bearer token or API key and loads the caller
+// into the context. Users who must use two-factor authentication but have
+// not enrolled yet are rejected until they complete setup.
+func AuthRequired() gin.HandlerFunc {
+	return authenticate(true, true)
+}
+
+// AuthRequiredForTwoFactorSetup is AuthRequired without the enrollment check,
+// for the routes users need to set up two-factor authentication. API keys
+// are not accepted.
+func AuthRequiredForTwoFactorSetup() gin.HandlerFunc {
+	return authenticate(false, false)
+}
+
+func authenticate(enforceTwoFactor, allowAPIKey bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if apiKey := c.GetHeader(APIKeyHeader); apiKey != "" && allowAPIKey {
+			authenticateAPIKey(c, apiKey)
+			return
+		}
+
+		header := c.GetHeader("Authorization")
+		tokenString, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || strings.TrimSpace(tokenString) == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.APIResponse{
+				Success: false,
+				Message: "Authorization header is required",
+				Error:   "missing_token",
+			})
+			return
+		}
+
+		claims, err := utils.ParseAccessToken(strings.TrimSpace(tokenString))
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.APIResponse{
+				Success: false,
+				Message: "Invalid or expired token",
+				Error:   "invalid_token",
+			})
+			return
+		}
+
+		// Reject tokens whose session was logged out or revoked
+		if !storage.IsSessionActive(claims.SessionID) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.APIResponse{
+				Success: false,
+				Message: "Session has been revoked",
+				Error:   "session_revoked",
+			})
+			return
+		}
+
+		// Load the user from storage so deleted accounts and
+		// role changes take effect before the token expires
+		user, exists := storage.GetUserByID(claims.UserID)
+		if !exists {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.APIResponse{
+				Success: false,
+				Message: "User no longer exists",
+				Error:   "invalid_token",
+			})
+			return
+		}
+
+		// Impersonation ends as soon as the admin loses access
+		if claims.ImpersonatorID != 0 {
+			impersonator, exists := storage.GetUserByID(claims.ImpersonatorID)
+			if !exists || !impersonator.HasAdminAccess() {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, models.APIResponse{
+					Success: false,
+					Message: "Impersonation is no longer allowed",
+					Error:   "invalid_token",
+				})
+				return
+			}
+			c.Set(ImpersonatorKey, impersonator)
+		}
+
+		settings := storage.GetSettings()
+		if enforceTwoFactor && settings.TwoFactorRequiredFor(&user) && !user.TwoFactorEnabled {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.APIResponse{
+				Success: false,
+				Message: "Two-factor authentication must be set up for this account",
+				Error:   "two_factor_setup_required",
+			})
+			return
+		}
+
+		storage.TouchSession(claims.SessionID, c.ClientIP())
+
+		c.Set(CurrentUserKey, user)
+		c.Set(ClaimsKey, claims)
+		c.Next()
+	}
+}
+
+// authenticateAPIKey loads the service account that owns the API key
+func authenticateAPIKey(c *gin.Context, apiKey string) {
+	key, exists := storage.GetAPIKeyByHash(utils.HashToken(apiKey))
+	if !exists || !key.IsActive() {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Invalid or revoked API key",
+			Error:   "invalid_api_key",
+		})
+		return
+	}
+
+	user, exists := storage.GetUserByID(key.UserID)
+	if !exists || !user.IsService() {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, models.APIResponse{
+			Success: false,
+			Message: "Invalid or revoked API key",
+			Error:   "invalid_api_key",
+		})
+		return
+	}
+
+	storage.TouchAPIKey(key.ID)
+
+	c.Set(CurrentUserKey, user)
+	c.Set(APIKeyKey, key)
+	c.Next()
+}
+
+// RequirePermission allows callers whose role grants permission, see
+// models.PermissionRegistry
+func RequirePermission(permission string) gin.HandlerFunc {
+	return authorize(func(u *models.User) bool {
+		return storage.RoleHasPermission(u.Type, permission)
+	})
+}
+
+// RequireOwner allows owner accounts only
+func RequireOwner() gin.HandlerFunc {
+	return authorize((*models.User).IsOwner)
+}
+
+// BlockImpersonation rejects requests made with an impersonation token, for
+// sensitive actions only the account holder may perform
+func BlockImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, impersonating := GetImpersonator(c); impersonating {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.APIResponse{
+				Success: false,
+				Message: "This action is not allowed while impersonating a user",
+				Error:   "impersonation_forbidden",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// authorize aborts with 403 unless the current user passes the check.
+// It must run after AuthRequired.
+func authorize(allowed func(u *models.User) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, ok := GetCurrentUser(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, models.APIResponse{
+				Success: false,
+				Message: "Authentication required",
+				Error:   "unauthorized",
+			})
+			return
+		}
+
+		// API keys grant the permissions of their scope, not of the account
+		user.Type = GetCurrentRole(c)
+
+		if !allowed(&user) {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.APIResponse{
+				Success: false,
+				Message: "You do not have permission to perform this action",
+				Error:   "forbidden",
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// GetCurrentUser returns the authenticated user stored by AuthRequired
+func GetCurrentUser(c *gin.Context) (models.User, bool) {
+	value, exists := c.Get(CurrentUserKey)
+	if !exists {
+		return models.User{}, false
+	}
+	user, ok := value.(models.User)
+	return user, ok
+}
+
+// GetCurrentRole returns the user type the request acts with. API keys act
+// with the role of their scope rather than the service account's type.
+func GetCurrentRole(c *gin.Context) string {
+	if key, ok := GetAPIKey(c); ok {
+		return key.Role()
+	}
+	user, _ := GetCurrentUser(c)
+	return user.Type
+}
+
+// GetClaims returns the access token claims stored by AuthRequired
+func GetClaims(c *gin.Context) (*utils.Claims, bool) {
+	value, exists := c.Get(ClaimsKey)
+	if !exists {
+		return nil, false
+	}
+	claims, ok := value.(*utils.Claims)
+	return claims, ok
+}
+
+// GetImpersonator returns the admin acting as the current user when the
+// request was made with an impersonation token
+func GetImpersonator(c *gin.Context) (models.User, bool) {
+	value, exists := c.Get(ImpersonatorKey)
+	if !exists {
+		return models.User{}, false
+	}
+	user, ok := value.(models.User)
+	return user, ok
+}
+
+// GetAPIKey returns the API key the request was authenticated with, if any
+func GetAPIKey(c *gin.Context) (models.APIKey, bool) {
+	value, exists := c.Get(APIKeyKey)
+	if !exists {
+		return models.APIKey{}, false
+	}
+	key, ok := value.(models.APIKey)
+	return key, ok
+}
@@ -1,20 +1,66 @@
 package middleware
 
 import (
-	"time"
+	"hr-backend-system/config"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
 
-// SetupCORS configures CORS middleware
+// SetupCORS applies the CORS policies from config. Route groups with their
+// own policy are matched by path prefix here rather than with group
+// middleware, so preflight requests to them are answered as well.
 func SetupCORS() gin.HandlerFunc {
-	return cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:3000", "http://localhost:8080"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
+	cfg := config.Get()
+
+	type groupCORS struct {
+		prefix  string
+		handler gin.HandlerFunc
+	}
+	groups := make([]groupCORS, 0, len(cfg.CORSGroups))
+	for _, policy := range cfg.CORSGroups {
+		groups = append(groups, groupCORS{prefix: policy.PathPrefix, handler: newCORS(policy)})
+	}
+	// Most specific prefix first
+	sort.Slice(groups, func(i, j int) bool {
+		return len(groups[i].prefix) > len(groups[j].prefix)
 	})
+	defaultHandler := newCORS(cfg.CORS)
+
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+		for _, group := range groups {
+			if path == group.prefix || strings.HasPrefix(path, group.prefix+"/") {
+				group.handler(c)
+				return
+			}
+		}
+		defaultHandler(c)
+	}
+}
+
+// newCORS builds the handler of one policy. Without allowed origins no
+// CORS headers are sent and browsers block cross-origin calls.
+func newCORS(policy config.CORSPolicy) gin.HandlerFunc {
+	if len(policy.AllowOrigins) == 0 {
+		return func(c *gin.Context) {}
+	}
+
+	corsConfig := cors.Config{
+		AllowMethods:     policy.AllowMethods,
+		AllowHeaders:     policy.AllowHeaders,
+		ExposeHeaders:    policy.ExposeHeaders,
+		AllowCredentials: policy.AllowCredentials,
+		MaxAge:           policy.MaxAge,
+		AllowWildcard:    true,
+	}
+	if slices.Contains(policy.AllowOrigins, "*") {
+		corsConfig.AllowAllOrigins = true
+	} else {
+		corsConfig.AllowOrigins = policy.AllowOrigins
+	}
+	return cors.New(corsConfig)
 }
@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"hr-backend-system/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSetupCORS(t *testing.T) {
+	cfg := config.Get()
+	policy, groups := cfg.CORS, cfg.CORSGroups
+	defer func() { cfg.CORS, cfg.CORSGroups = policy, groups }()
+
+	base := config.CORSPolicy{
+		AllowMethods:     []string{"GET", "POST"},
+		AllowHeaders:     []string{"Content-Type", "Authorization"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	}
+	cfg.CORS = base
+	cfg.CORS.AllowOrigins = []string{"https://app.example.com", "https://*.admin.example.com"}
+	careers := base
+	careers.PathPrefix = "/api/v1/careers"
+	careers.AllowOrigins = []string{"*"}
+	careers.AllowCredentials = false
+	cfg.CORSGroups = []config.CORSPolicy{careers}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(SetupCORS())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/api/v1/users", ok)
+	router.GET("/api/v1/careers/jobs", ok)
+	router.GET("/api/v1/careersx", ok)
+
+	tests := []struct {
+		name            string
+		path            string
+		origin          string
+		wantOrigin      string
+		wantCredentials bool
+		wantStatus      int
+	}{
+		{"allowed origin", "/api/v1/users", "https://app.example.com", "https://app.example.com", true, http.StatusOK},
+		{"wildcard subdomain", "/api/v1/users", "https://eu.admin.example.com", "https://eu.admin.example.com", true, http.StatusOK},
+		{"other origin", "/api/v1/users", "https://evil.example.net", "", false, http.StatusForbidden},
+		{"lookalike origin", "/api/v1/users", "https://app.example.com.evil.net", "", false, http.StatusForbidden},
+		{"other scheme", "/api/v1/users", "http://app.example.com", "", false, http.StatusForbidden},
+		{"same-origin request", "/api/v1/users", "", "", false, http.StatusOK},
+		{"public group", "/api/v1/careers/jobs", "https://evil.example.net", "*", false, http.StatusOK},
+		{"prefix of a group path", "/api/v1/careersx", "https://evil.example.net", "", false, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials") == "true"; got != tt.wantCredentials {
+				t.Errorf("credentials allowed = %v, want %v", got, tt.wantCredentials)
+			}
+		})
+	}
+}
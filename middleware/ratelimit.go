@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"hr-backend-system/config"
+	"hr-backend-system/models"
+	"hr-backend-system/ratelimit"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit applies the quota of a route group, see config.RateLimitFor.
+// Callers are identified by API key, then user ID, then client IP, so it
+// should run after AuthRequired on protected groups, behind RateLimitIP.
+func RateLimit(group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := config.Get()
+		if !cfg.RateLimitEnabled {
+			c.Next()
+			return
+		}
+		takeRateLimit(c, group+":"+rateLimitIdentity(c), cfg.RateLimitFor(group))
+	}
+}
+
+// RateLimitIP applies the "ip" quota per client IP. It runs in front of
+// AuthRequired, so requests with invalid tokens or API keys are limited
+// before they are checked.
+func RateLimitIP() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cfg := config.Get()
+		if !cfg.RateLimitEnabled {
+			c.Next()
+			return
+		}
+		takeRateLimit(c, "ip:"+c.ClientIP(), cfg.RateLimitFor("ip"))
+	}
+}
+
+// takeRateLimit takes a request from the quota tracked under key, aborting
+// with 429 once it is used up
+func takeRateLimit(c *gin.Context, key string, limit config.RateLimit) {
+	result := ratelimit.Take(key, limit)
+	if result.Limit == 0 {
+		c.Next()
+		return
+	}
+
+	c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("X-RateLimit-Reset", ceilSeconds(result.ResetAfter))
+
+	if !result.Allowed {
+		c.Header("Retry-After", ceilSeconds(result.RetryAfter))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, models.APIResponse{
+			Success: false,
+			Message: "Too many requests, please try again later",
+			Error:   "rate_limited",
+		})
+		return
+	}
+
+	c.Next()
+}
+
+// rateLimitIdentity returns the key the caller's quota is tracked under
+func rateLimitIdentity(c *gin.Context) string {
+	if key, ok := GetAPIKey(c); ok {
+		return "key:" + strconv.Itoa(key.ID)
+	}
+	if user, ok := GetCurrentUser(c); ok {
+		return "user:" + strconv.Itoa(user.ID)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
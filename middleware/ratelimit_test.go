@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"hr-backend-system/config"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitIPBeforeAuth(t *testing.T) {
+	cfg := config.Get()
+	enabled, limits := cfg.RateLimitEnabled, cfg.RateLimits
+	cfg.RateLimitEnabled = true
+	cfg.RateLimits = map[string]config.RateLimit{"ip": {Requests: 2, Period: time.Hour}}
+	defer func() { cfg.RateLimitEnabled, cfg.RateLimits = enabled, limits }()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", RateLimitIP(), AuthRequired(), RateLimit("users"), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := []struct {
+		remoteAddr string
+		wantStatus int
+	}{
+		{"198.51.100.7:1000", http.StatusUnauthorized},
+		{"198.51.100.7:1001", http.StatusUnauthorized},
+		// Invalid tokens are limited like any other request
+		{"198.51.100.7:1002", http.StatusTooManyRequests},
+		{"198.51.100.8:1000", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = tt.remoteAddr
+		req.Header.Set("Authorization", "Bearer invalid")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		if w.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d: %s", tt.remoteAddr, w.Code, tt.wantStatus, w.Body.String())
+		}
+	}
+}
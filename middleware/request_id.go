@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"hr-backend-system/utils"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader carries the request ID in requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDKey is the context key set by RequestID
+const RequestIDKey = "requestID"
+
+// validRequestID limits the IDs accepted from clients or proxies
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,128}$`)
+
+// RequestID reuses a well-formed X-Request-ID header or generates one, and
+// echoes it in the response so log lines and audit entries can be matched
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id, _ = utils.GenerateID()
+		}
+		c.Set(RequestIDKey, id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// GetRequestID returns the ID assigned by RequestID
+func GetRequestID(c *gin.Context) string {
+	return c.GetString(RequestIDKey)
+}
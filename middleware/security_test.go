@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"hr-backend-system/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newSecurityRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(SecurityHeaders(), CSRFProtection())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/", ok)
+	router.POST("/", ok)
+	return router
+}
+
+func TestCSRFProtection(t *testing.T) {
+	cfg := config.Get()
+	router := newSecurityRouter()
+	cookie := &http.Cookie{Name: cfg.CSRFCookieName, Value: "csrf-token"}
+	session := &http.Cookie{Name: cfg.AuthCookieName, Value: "value"}
+	other := &http.Cookie{Name: "oidc_state", Value: "value"}
+
+	tests := []struct {
+		name       string
+		cookies    []*http.Cookie
+		headers    map[string]string
+		wantStatus int
+	}{
+		{"no cookies", nil, nil, http.StatusOK},
+		{"only the CSRF cookie", []*http.Cookie{cookie}, nil, http.StatusOK},
+		{"unrelated cookie", []*http.Cookie{cookie, other}, nil, http.StatusOK},
+		{"matching token", []*http.Cookie{cookie, session}, map[string]string{cfg.CSRFHeaderName: "csrf-token"}, http.StatusOK},
+		{"missing header", []*http.Cookie{cookie, session}, nil, http.StatusForbidden},
+		{"mismatched header", []*http.Cookie{cookie, session}, map[string]string{cfg.CSRFHeaderName: "other"}, http.StatusForbidden},
+		{"missing cookie", []*http.Cookie{session}, map[string]string{cfg.CSRFHeaderName: "csrf-token"}, http.StatusForbidden},
+		{"bearer token", []*http.Cookie{session}, map[string]string{"Authorization": "Bearer token"}, http.StatusOK},
+		{"API key", []*http.Cookie{session}, map[string]string{APIKeyHeader: "key"}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			for _, c := range tt.cookies {
+				req.AddCookie(c)
+			}
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusForbidden && !strings.Contains(w.Body.String(), "invalid_csrf_token") {
+				t.Errorf("body = %s, want invalid_csrf_token", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestCSRFCookieIssued(t *testing.T) {
+	cfg := config.Get()
+	router := newSecurityRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := w.Header().Get("Set-Cookie"); !strings.HasPrefix(got, cfg.CSRFCookieName+"=") || !strings.Contains(got, "SameSite=Lax") {
+		t.Errorf("Set-Cookie = %q, want a SameSite=Lax %s cookie", got, cfg.CSRFCookieName)
+	}
+
+	// An existing token is kept
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: cfg.CSRFCookieName, Value: "csrf-token"})
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if got := w.Header().Get("Set-Cookie"); got != "" {
+		t.Errorf("Set-Cookie = %q, want none", got)
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	cfg := config.Get()
+	saved := *cfg
+	defer func() { *cfg = saved }()
+	cfg.HSTSMaxAge = time.Hour
+	cfg.HSTSIncludeSubdomains = true
+	cfg.FrameAncestors = "'none'"
+	cfg.ReferrerPolicy = "no-referrer"
+
+	w := httptest.NewRecorder()
+	newSecurityRouter().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	want := map[string]string{
+		"Strict-Transport-Security": "max-age=3600; includeSubDomains",
+		"X-Content-Type-Options":    "nosniff",
+		"Content-Security-Policy":   "frame-ancestors 'none'",
+		"Referrer-Policy":           "no-referrer",
+	}
+	for header, value := range want {
+		if got := w.Header().Get(header); got != value {
+			t.Errorf("%s = %q, want %q", header, got, value)
+		}
+	}
+}
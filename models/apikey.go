@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// API key scopes
+const (
+	APIKeyScopeRead  = "read"  // Same access as a viewer
+	APIKeyScopeWrite = "write" // Same access as an operator
+)
+
+// APIKey represents a credential of a service account. Only the hash of
+// the key is stored, Prefix identifies the key in listings.
+type APIKey struct {
+	ID         int        `json:"id" example:"1"`
+	UserID     int        `json:"user_id" example:"7"`
+	Name       string     `json:"name" example:"payroll sync"`
+	Prefix     string     `json:"prefix" example:"hrk_Zx81kQ2a"`
+	KeyHash    string     `json:"-"`
+	Scope      string     `json:"scope" example:"read"`
+	CreatedBy  int        `json:"created_by" example:"1"`
+	CreatedAt  time.Time  `json:"created_at" example:"2025-07-02T15:04:05Z"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" example:"2025-07-02T16:04:05Z"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// IsActive reports whether the key can still be used
+func (k *APIKey) IsActive() bool {
+	return k.RevokedAt == nil
+}
+
+// Role returns the user type whose permissions the key's scope grants
+func (k *APIKey) Role() string {
+	if k.Scope == APIKeyScopeWrite {
+		return UserTypeOperator
+	}
+	return UserTypeViewer
+}
+
+// CreateServiceAccountRequest represents the request payload for creating a service account
+type CreateServiceAccountRequest struct {
+	Name string `json:"name" binding:"required,min=2,max=100" example:"Payroll integration"`
+}
+
+// CreateAPIKeyRequest represents the request payload for issuing an API key
+type CreateAPIKeyRequest struct {
+	Name  string `json:"name" binding:"required,min=2,max=100" example:"payroll sync"`
+	Scope string `json:"scope" binding:"required,oneof=read write" example:"read"`
+}
+
+// APIKeyCreatedResponse contains a new API key. The plaintext key is only returned once.
+type APIKeyCreatedResponse struct {
+	Key    string `json:"key" example:"hrk_Zx81kQ2a..."`
+	APIKey APIKey `json:"api_key"`
+}
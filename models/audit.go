@@ -0,0 +1,78 @@
+package models
+
+import "time"
+
+// Audit actions recorded for user and policy mutations
+const (
+	AuditUserCreated              = "user.created"
+	AuditUserRegistered           = "user.registered"
+	AuditUserUpdated              = "user.updated"
+	AuditUserDeleted              = "user.deleted"
+	AuditUserAnonymized           = "user.anonymized"
+	AuditUserPasswordChanged      = "user.password_changed"
+	AuditUserPasswordReset        = "user.password_reset"
+	AuditUserEmailVerified        = "user.email_verified"
+	AuditUserTwoFactorEnabled     = "user.two_factor_enabled"
+	AuditUserTwoFactorDisabled    = "user.two_factor_disabled"
+	AuditUserRecoveryCodesReset   = "user.recovery_codes_regenerated"
+	AuditUserRoleChanged          = "user.role_changed"
+	AuditUserOwnershipTransferred = "user.ownership_transferred"
+	AuditServiceAccountCreated    = "service_account.created"
+
+	AuditSettingsUpdated = "settings.updated"
+	AuditRoleUpdated     = "role.updated"
+)
+
+// AuditEntry records who changed a user account and how. Entries are
+// never updated or deleted.
+type AuditEntry struct {
+	ID             int           `json:"id" example:"1"`
+	Action         string        `json:"action" example:"user.updated"`
+	ActorID        int           `json:"actor_id,omitempty" example:"1"`        // User or service account that made the change, zero for the system
+	APIKeyID       int           `json:"api_key_id,omitempty" example:"3"`      // Set when the actor used an API key
+	ImpersonatorID int           `json:"impersonator_id,omitempty" example:"2"` // Admin acting as the actor
+	TargetID       int           `json:"target_id" example:"12"`                // Changed user
+	Changes        []FieldChange `json:"changes,omitempty"`
+	IP             string        `json:"ip,omitempty" example:"203.0.113.7"`
+	RequestID      string        `json:"request_id,omitempty" example:"6f1c0b8e2d5a4f93"`
+	CreatedAt      time.Time     `json:"created_at" example:"2025-07-02T15:04:05Z"`
+}
+
+// FieldChange is one changed field of an audit entry. Secret fields such
+// as the password only record that they changed, never their values.
+type FieldChange struct {
+	Field    string `json:"field" example:"name"`
+	Before   any    `json:"before,omitempty"`
+	After    any    `json:"after,omitempty"`
+	Redacted bool   `json:"redacted,omitempty" example:"false"`
+}
+
+// AuditFilter selects audit entries, zero values match everything
+type AuditFilter struct {
+	Action    string
+	ActorID   int
+	TargetID  int
+	RequestID string
+	From      time.Time
+	To        time.Time
+	Limit     int
+}
+
+// Matches reports whether entry passes the filter, ignoring Limit
+func (f *AuditFilter) Matches(entry *AuditEntry) bool {
+	switch {
+	case f.Action != "" && entry.Action != f.Action:
+		return false
+	case f.ActorID != 0 && entry.ActorID != f.ActorID:
+		return false
+	case f.TargetID != 0 && entry.TargetID != f.TargetID:
+		return false
+	case f.RequestID != "" && entry.RequestID != f.RequestID:
+		return false
+	case !f.From.IsZero() && entry.CreatedAt.Before(f.From):
+		return false
+	case !f.To.IsZero() && !entry.CreatedAt.Before(f.To):
+		return false
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package models
+
+import "time"
+
+// LoginResponse represents the payload returned after a successful login or refresh
+type LoginResponse struct {
+	AccessToken           string       `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	TokenType             string       `json:"token_type" example:"Bearer"`
+	ExpiresIn             int          `json:"expires_in" example:"900"`
+	ExpiresAt             time.Time    `json:"expires_at" example:"2025-07-02T15:19:05Z"`
+	RefreshToken          string       `json:"refresh_token" example:"hJ3k...Q9w"`
+	RefreshTokenExpiresAt time.Time    `json:"refresh_token_expires_at" example:"2025-07-09T15:04:05Z"`
+	User                  UserResponse `json:"user"`
+}
+
+// RefreshRequest represents the request payload for refreshing or revoking a session
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required" example:"hJ3k...Q9w"`
+}
+
+// Session represents a login session, the family of refresh tokens
+// issued from a single login
+type Session struct {
+	ID         string     `json:"id" example:"b3f1c2d4e5f60718293a4b5c6d7e8f90"`
+	UserID     int        `json:"user_id" example:"1"`
+	UserAgent  string     `json:"user_agent" example:"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7)"`
+	IP         string     `json:"ip" example:"203.0.113.7"` // Address of the most recent activity
+	CreatedAt  time.Time  `json:"created_at" example:"2025-07-02T15:04:05Z"`
+	LastSeenAt time.Time  `json:"last_seen_at" example:"2025-07-02T15:04:05Z"`
+	ExpiresAt  time.Time  `json:"expires_at" example:"2025-07-09T15:04:05Z"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+
+	ImpersonatorID int `json:"impersonator_id,omitempty" example:"1"` // Admin acting as the user
+}
+
+// IsActive reports whether the session can still be used
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
+
+// SessionResponse represents a session in API responses
+type SessionResponse struct {
+	Session
+	Current bool `json:"current" example:"true"` // Session the request was made with
+}
+
+// RefreshToken represents a single-use refresh token. Only the hash of
+// the token is stored.
+type RefreshToken struct {
+	TokenHash string
+	SessionID string
+	UserID    int
+	ExpiresAt time.Time
+	CreatedAt time.Time
+	UsedAt    *time.Time
+}
+
+// ForgotPasswordRequest represents the request payload for requesting a password reset
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email" example:"john@example.com"`
+}
+
+// ResetPasswordRequest represents the request payload for resetting a password with a reset token
+type ResetPasswordRequest struct {
+	Token           string `json:"token" binding:"required" example:"hJ3k...Q9w"`
+	NewPassword     string `json:"new_password" binding:"required,min=8,max=128" example:"newpassword456"`
+	ConfirmPassword string `json:"confirm_password" binding:"required,eqfield=NewPassword" example:"newpassword456"`
+}
+
+// One-time token purposes
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposeTwoFactorLogin    = "two_factor_login"
+)
+
+// OneTimeToken represents a single-use token sent to the user by email.
+// Only the hash of the token is stored.
+type OneTimeToken struct {
+	TokenHash string
+	Purpose   string
+	UserID    int
+	Data      string // Purpose specific payload, e.g. the email address being verified
+	Attempts  int    // Failed attempts, for tokens that are checked together with a code
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// VerifyEmailRequest represents the request payload for confirming an email address
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required" example:"hJ3k...Q9w"`
+}
+
+// ResendVerificationRequest represents the request payload for resending a verification email
+type ResendVerificationRequest struct {
+	Email string `json:"email" binding:"required,email" example:"john@example.com"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Data export statuses
+const (
+	DataExportPending = "pending"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+)
+
+// Data export formats
+const (
+	DataExportFormatJSON = "json"
+	DataExportFormatZIP  = "zip"
+)
+
+// DataExport is a bundle of everything stored about a user, generated in
+// the background for data subject access requests
+type DataExport struct {
+	ID          string     `json:"id" example:"4b227777d4dd1fc61c6f884f48641d02"`
+	UserID      int        `json:"user_id" example:"12"`
+	RequestedBy int        `json:"requested_by" example:"12"`
+	Format      string     `json:"format" example:"zip"`
+	Status      string     `json:"status" example:"ready"`
+	Error       string     `json:"error,omitempty" example:"failed to write export"`
+	Size        int64      `json:"size,omitempty" example:"18342"`
+	DownloadURL string     `json:"download_url,omitempty" example:"http://localhost:8080/api/v1/users/12/exports/4b227777d4dd1fc61c6f884f48641d02/download"`
+	CreatedAt   time.Time  `json:"created_at" example:"2025-07-02T15:04:05Z"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   time.Time  `json:"expires_at" example:"2025-07-03T15:04:05Z"`
+	FilePath    string     `json:"-"`
+}
+
+// DataExportBundle is the content of a data export. Password hashes,
+// two-factor secrets and token hashes are never included.
+type DataExportBundle struct {
+	GeneratedAt        time.Time           `json:"generated_at"`
+	User               UserResponse        `json:"user"`
+	Sessions           []Session           `json:"sessions"`
+	OIDCIdentities     []OIDCIdentity      `json:"oidc_identities"`
+	APIKeys            []APIKey            `json:"api_keys"`
+	OwnershipTransfers []OwnershipTransfer `json:"ownership_transfers"`
+	RoleChangeRequests []RoleChangeRequest `json:"role_change_requests"`
+	SecurityEvents     []SecurityEvent     `json:"security_events"`
+	AuditEntries       []AuditEntry        `json:"audit_entries"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// OIDCProviderResponse describes a login provider offered to clients
+type OIDCProviderResponse struct {
+	Name        string `json:"name" example:"google"`
+	DisplayName string `json:"display_name" example:"Google"`
+	LoginURL    string `json:"login_url" example:"/api/v1/auth/oidc/google/login"`
+}
+
+// OIDCLoginState is kept between redirecting to a provider and its callback.
+// It binds the callback to the login that started it and holds the PKCE verifier.
+type OIDCLoginState struct {
+	StateHash    string
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+}
+
+// OIDCIdentity links an account at an identity provider to a local user
+type OIDCIdentity struct {
+	Provider  string    `json:"provider" example:"google"`
+	Subject   string    `json:"subject" example:"110169484474386276334"`
+	UserID    int       `json:"user_id" example:"1"`
+	Email     string    `json:"email" example:"john@example.com"`
+	CreatedAt time.Time `json:"created_at" example:"2025-07-02T15:04:05Z"`
+}
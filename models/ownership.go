@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Ownership transfer statuses
+const (
+	OwnershipTransferPending   = "pending"
+	OwnershipTransferAccepted  = "accepted"
+	OwnershipTransferDeclined  = "declined"
+	OwnershipTransferCancelled = "cancelled"
+	OwnershipTransferExpired   = "expired"
+)
+
+// OwnershipTransfer is an owner's offer to hand the owner role to another
+// staff account. It takes effect once the recipient accepts.
+type OwnershipTransfer struct {
+	ID          string     `json:"id" example:"9f86d081884c7d659a2feaa0c55ad015"`
+	FromUserID  int        `json:"from_user_id" example:"1"`
+	ToUserID    int        `json:"to_user_id" example:"2"`
+	Status      string     `json:"status" example:"pending"`
+	CreatedAt   time.Time  `json:"created_at" example:"2025-07-02T15:04:05Z"`
+	ExpiresAt   time.Time  `json:"expires_at" example:"2025-07-05T15:04:05Z"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// IsPending reports whether the transfer can still be accepted
+func (t *OwnershipTransfer) IsPending(now time.Time) bool {
+	return t.Status == OwnershipTransferPending && now.Before(t.ExpiresAt)
+}
+
+// StartOwnershipTransferRequest represents the request payload for offering
+// ownership to another account. The current owner confirms with their
+// password and, if enabled, a two-factor code.
+type StartOwnershipTransferRequest struct {
+	RecipientID int    `json:"recipient_id" binding:"required,min=1" example:"2"`
+	Password    string `json:"password" binding:"required" example:"yourpassword"`
+	Code        string `json:"code,omitempty" example:"123456"`
+}
@@ -0,0 +1,111 @@
+package models
+
+import "time"
+
+// Permissions checked by the API. New modules add their permissions here
+// and to PermissionRegistry.
+const (
+	PermUsersRead             = "users:read"
+	PermUsersCreate           = "users:create"
+	PermUsersUpdate           = "users:update"
+	PermUsersDelete           = "users:delete"
+	PermUsersUnlock           = "users:unlock"
+	PermUsersImpersonate      = "users:impersonate"
+	PermUsersExport           = "users:export"
+	PermSessionsManage        = "sessions:manage"
+	PermServiceAccountsManage = "service_accounts:manage"
+	PermSecurityRead          = "security:read"
+	PermSecurityManage        = "security:manage"
+	PermAuditRead             = "audit:read"
+	PermSettingsRead          = "settings:read"
+	PermSettingsUpdate        = "settings:update"
+	PermRolesRead             = "roles:read"
+	PermRoleRequestsCreate    = "role_requests:create"
+	PermJobsRead              = "jobs:read"
+	PermJobsPublish           = "jobs:publish"
+)
+
+// Permission describes an entry of the permission registry
+type Permission struct {
+	Name        string `json:"name" example:"users:read"`
+	Description string `json:"description" example:"View user accounts"`
+}
+
+// PermissionRegistry lists every permission that can be granted to a role
+var PermissionRegistry = []Permission{
+	{PermUsersRead, "View user accounts"},
+	{PermUsersCreate, "Create user accounts"},
+	{PermUsersUpdate, "Edit user accounts"},
+	{PermUsersDelete, "Delete user accounts"},
+	{PermUsersUnlock, "Unlock accounts locked after failed logins"},
+	{PermUsersImpersonate, "Act as a job seeker or organization account for support"},
+	{PermUsersExport, "Export any user's personal data, users can always export their own"},
+	{PermSessionsManage, "View and revoke other users' sessions"},
+	{PermServiceAccountsManage, "Manage service accounts and API keys"},
+	{PermSecurityRead, "View security events"},
+	{PermSecurityManage, "Unlock IP addresses and rotate encryption keys"},
+	{PermAuditRead, "View the audit log of user changes"},
+	{PermSettingsRead, "View system settings"},
+	{PermSettingsUpdate, "Change system settings"},
+	{PermRolesRead, "View roles, permissions and role change requests"},
+	{PermRoleRequestsCreate, "Request staff roles for external accounts"},
+	{PermJobsRead, "View job postings"},
+	{PermJobsPublish, "Publish job postings"},
+}
+
+// IsKnownPermission reports whether name is in the permission registry
+func IsKnownPermission(name string) bool {
+	for _, p := range PermissionRegistry {
+		if p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultRolePermissions seeds the role of each user type. The owner role
+// always has every permission and is not editable.
+var DefaultRolePermissions = map[string][]string{
+	UserTypeViewer: {
+		PermUsersRead, PermJobsRead,
+	},
+	UserTypeOperator: {
+		PermUsersRead, PermUsersCreate, PermUsersUpdate, PermJobsRead, PermJobsPublish,
+	},
+	UserTypeAdmin: {
+		PermUsersRead, PermUsersCreate, PermUsersUpdate, PermUsersDelete, PermUsersUnlock,
+		PermUsersImpersonate, PermUsersExport, PermSessionsManage, PermServiceAccountsManage,
+		PermSecurityRead, PermSecurityManage, PermAuditRead,
+		PermSettingsRead, PermSettingsUpdate, PermRolesRead, PermRoleRequestsCreate,
+		PermJobsRead, PermJobsPublish,
+	},
+	UserTypeJobSeeker: {
+		PermJobsRead,
+	},
+	UserTypeOrganization: {
+		PermJobsRead, PermJobsPublish,
+	},
+}
+
+// Role maps a user type to the permissions it grants
+type Role struct {
+	Name        string    `json:"name" example:"operator"`
+	Permissions []string  `json:"permissions" example:"users:read,users:create"`
+	Editable    bool      `json:"editable" example:"true"`
+	UpdatedAt   time.Time `json:"updated_at" example:"2025-07-02T15:04:05Z"`
+}
+
+// HasPermission reports whether the role grants permission
+func (r *Role) HasPermission(permission string) bool {
+	for _, p := range r.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateRoleRequest represents the request payload for replacing a role's permissions
+type UpdateRoleRequest struct {
+	Permissions []string `json:"permissions" binding:"required" example:"users:read,jobs:read"`
+}
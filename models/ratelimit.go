@@ -0,0 +1,9 @@
+package models
+
+import "time"
+
+// TokenBucket is the rate limiter state of one caller in one route group
+type TokenBucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Role change request statuses
+const (
+	RoleRequestPending  = "pending"
+	RoleRequestApproved = "approved"
+	RoleRequestRejected = "rejected"
+)
+
+// RoleChangeRequest asks an owner to convert an external account into a
+// staff role. External accounts never become staff without owner approval.
+type RoleChangeRequest struct {
+	ID          int        `json:"id" example:"1"`
+	UserID      int        `json:"user_id" example:"12"`
+	FromType    string     `json:"from_type" example:"jobseeker"`
+	ToType      string     `json:"to_type" example:"operator"`
+	Reason      string     `json:"reason,omitempty" example:"Hired as recruiter"`
+	Status      string     `json:"status" example:"pending"`
+	RequestedBy int        `json:"requested_by" example:"2"`
+	ReviewedBy  int        `json:"reviewed_by,omitempty" example:"1"`
+	CreatedAt   time.Time  `json:"created_at" example:"2025-07-02T15:04:05Z"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// CreateRoleChangeRequest represents the request payload for asking an
+// owner to give an external account a staff role
+type CreateRoleChangeRequest struct {
+	UserID int    `json:"user_id" binding:"required,min=1" example:"12"`
+	Type   string `json:"type" binding:"required,oneof=viewer operator admin" example:"operator"`
+	Reason string `json:"reason,omitempty" binding:"max=500" example:"Hired as recruiter"`
+}
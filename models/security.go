@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// Security event types
+const (
+	SecurityEventAccountLocked   = "account_locked"
+	SecurityEventIPLocked        = "ip_locked"
+	SecurityEventAccountUnlocked = "account_unlocked"
+	SecurityEventIPUnlocked      = "ip_unlocked"
+
+	SecurityEventImpersonationStarted = "impersonation_started"
+	SecurityEventImpersonationStopped = "impersonation_stopped"
+
+	SecurityEventEncryptionKeyRotated = "encryption_key_rotated"
+
+	SecurityEventAdminTwoFactorPolicyChanged = "admin_two_factor_policy_changed"
+)
+
+// LoginAttempt tracks failed authentication attempts for an account or IP address
+type LoginAttempt struct {
+	Key            string    `json:"key" example:"account:john@example.com"`
+	Failures       int       `json:"failures" example:"3"`
+	FirstFailureAt time.Time `json:"first_failure_at" example:"2025-07-02T15:04:05Z"`
+	LastFailureAt  time.Time `json:"last_failure_at" example:"2025-07-02T15:06:05Z"`
+	LockedUntil    time.Time `json:"locked_until,omitempty" example:"2025-07-02T15:21:05Z"`
+}
+
+// IsLocked reports whether the lockout is still in effect
+func (a *LoginAttempt) IsLocked(now time.Time) bool {
+	return now.Before(a.LockedUntil)
+}
+
+// SecurityEvent records a security relevant occurrence such as a lockout
+type SecurityEvent struct {
+	ID        int       `json:"id" example:"1"`
+	Type      string    `json:"type" example:"account_locked"`
+	Email     string    `json:"email,omitempty" example:"john@example.com"`
+	IP        string    `json:"ip,omitempty" example:"203.0.113.7"`
+	ActorID   int       `json:"actor_id,omitempty" example:"1"` // Admin who triggered the event, if any
+	UserID    int       `json:"user_id,omitempty" example:"12"` // Account the event concerns, if any
+	Details   string    `json:"details,omitempty" example:"5 failed attempts"`
+	CreatedAt time.Time `json:"created_at" example:"2025-07-02T15:04:05Z"`
+}
+
+// UnlockIPRequest represents the request payload for lifting an IP lockout
+type UnlockIPRequest struct {
+	IP string `json:"ip" binding:"required,ip" example:"203.0.113.7"`
+}
+
+// ImpersonationResponse represents the token issued to act as another user
+type ImpersonationResponse struct {
+	AccessToken    string       `json:"access_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	TokenType      string       `json:"token_type" example:"Bearer"`
+	ExpiresIn      int          `json:"expires_in" example:"1800"`
+	ExpiresAt      time.Time    `json:"expires_at" example:"2025-07-02T15:34:05Z"`
+	ImpersonatorID int          `json:"impersonator_id" example:"1"`
+	User           UserResponse `json:"user"`
+}
+
+// KeyRotationResponse reports the outcome of an encryption key rotation
+type KeyRotationResponse struct {
+	KeyID            string `json:"key_id" example:"9f86d081884c7d65"` // Master key new data is encrypted with
+	ReencryptedUsers int    `json:"reencrypted_users" example:"42"`    // Users whose data was encrypted with an older key
+}
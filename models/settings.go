@@ -0,0 +1,28 @@
+package models
+
+// What deleting a user does
+const (
+	UserDeletionDelete    = "delete"    // Remove the record
+	UserDeletionAnonymize = "anonymize" // Keep the record with personal data scrubbed
+)
+
+// Settings represents system-wide policies that administrators can change at runtime
+type Settings struct {
+	RequireEmailVerification bool   `json:"require_email_verification" example:"false"`
+	RequireAdminTwoFactor    bool   `json:"require_admin_two_factor" example:"true"`
+	UserDeletionMode         string `json:"user_deletion_mode" example:"delete"`
+}
+
+// TwoFactorRequiredFor reports whether the policy makes two-factor
+// authentication mandatory for the user
+func (s *Settings) TwoFactorRequiredFor(u *User) bool {
+	return s.RequireAdminTwoFactor && u.HasAdminAccess()
+}
+
+// UpdateSettingsRequest represents the request payload for updating settings.
+// Omitted fields are left unchanged.
+type UpdateSettingsRequest struct {
+	RequireEmailVerification *bool   `json:"require_email_verification,omitempty" example:"true"`
+	RequireAdminTwoFactor    *bool   `json:"require_admin_two_factor,omitempty" example:"true"`
+	UserDeletionMode         *string `json:"user_deletion_mode,omitempty" binding:"omitempty,oneof=delete anonymize" example:"anonymize"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// TwoFactorSetupResponse represents a pending TOTP enrollment
+type TwoFactorSetupResponse struct {
+	Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXP"`
+	OTPAuthURL string `json:"otpauth_url" example:"otpauth://totp/HR%20Backend%20System:john@example.com?secret=JBSWY3DPEHPK3PXP&issuer=HR%20Backend%20System"`
+	QRCode     string `json:"qr_code" example:"data:image/png;base64,iVBORw0KGgo..."`
+}
+
+// TwoFactorCodeRequest represents a request confirmed with a TOTP code
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required,len=6,numeric" example:"123456"`
+}
+
+// DisableTwoFactorRequest represents the request payload for turning off two-factor authentication
+type DisableTwoFactorRequest struct {
+	Password string `json:"password" binding:"required" example:"yourpassword"`
+	Code     string `json:"code" binding:"required,len=6,numeric" example:"123456"`
+}
+
+// RecoveryCodesResponse represents newly generated recovery codes, shown only once
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"k3j9d-x8w2q,p0s7c-m4n1b"`
+}
+
+// TwoFactorChallengeResponse is returned by login when a second factor is needed
+type TwoFactorChallengeResponse struct {
+	TwoFactorRequired bool      `json:"two_factor_required" example:"true"`
+	ChallengeToken    string    `json:"challenge_token" example:"hJ3k...Q9w"`
+	ExpiresAt         time.Time `json:"expires_at" example:"2025-07-02T15:09:05Z"`
+}
+
+// TwoFactorLoginRequest represents the second login step. Either Code or
+// RecoveryCode must be set.
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required" example:"hJ3k...Q9w"`
+	Code           string `json:"code,omitempty" binding:"omitempty,len=6,numeric" example:"123456"`
+	RecoveryCode   string `json:"recovery_code,omitempty" example:"k3j9d-x8w2q"`
+}
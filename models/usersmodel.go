@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"time"
+)
 
 // User role constants
 const (
@@ -10,17 +13,66 @@ const (
 	UserTypeOwner        = "owner"        // Complete system control
 	UserTypeJobSeeker    = "jobseeker"    // Job seekers/applicants
 	UserTypeOrganization = "organization" // Company/employer accounts
+	UserTypeService      = "service"      // Integrations authenticating with API keys
 )
 
+// IsSelfRegisterableType reports whether a user type can be chosen during
+// public registration. Staff roles are only assigned by administrators.
+func IsSelfRegisterableType(userType string) bool {
+	return IsExternalType(userType)
+}
+
+// roleRanks orders the staff roles. External and service accounts rank
+// below every staff role.
+var roleRanks = map[string]int{
+	UserTypeViewer:   1,
+	UserTypeOperator: 2,
+	UserTypeAdmin:    3,
+	UserTypeOwner:    4,
+}
+
+// RoleRank returns the position of a user type in the role hierarchy
+func RoleRank(userType string) int {
+	return roleRanks[userType]
+}
+
+// IsStaffType reports whether a user type is an internal staff role
+func IsStaffType(userType string) bool {
+	return RoleRank(userType) > 0
+}
+
+// IsExternalType reports whether a user type belongs to people outside the
+// company, such as applicants and employers
+func IsExternalType(userType string) bool {
+	return userType == UserTypeJobSeeker || userType == UserTypeOrganization
+}
+
+// CanAssignRole reports whether a caller acting as callerType may give
+// userType to an account. Only roles strictly below the caller's own can
+// be assigned.
+func CanAssignRole(callerType, userType string) bool {
+	return IsStaffType(callerType) && RoleRank(userType) < RoleRank(callerType)
+}
+
 // User represents a user in our system
 type User struct {
-	ID        int       `json:"id" example:"1"`
-	Name      string    `json:"name" example:"John Doe"`
-	Email     string    `json:"email" example:"john@example.com"`
-	Type      string    `json:"type" example:"jobseeker"`
-	Password  string    `json:"-"` // Do not expose in JSON responses
-	CreatedAt time.Time `json:"created_at" example:"2025-07-02T15:04:05Z"`
-	UpdatedAt time.Time `json:"updated_at" example:"2025-07-02T15:04:05Z"`
+	ID                 int        `json:"id" example:"1"`
+	Name               string     `json:"name" example:"John Doe"`
+	Email              string     `json:"email" example:"john@example.com"`
+	EmailVerified      bool       `json:"email_verified" example:"true"`
+	PendingEmail       string     `json:"pending_email,omitempty" example:"john.new@example.com"` // Awaiting verification, Email stays active until then
+	PhoneNumber        string     `json:"phone_number,omitempty" example:"080-1234-5678"`
+	Type               string     `json:"type" example:"jobseeker"`
+	Password           string     `json:"-"` // Do not expose in JSON responses
+	PasswordHistory    []string   `json:"-"` // Recent password hashes, used to prevent reuse
+	TwoFactorEnabled   bool       `json:"two_factor_enabled" example:"false"`
+	TOTPSecret         string     `json:"-"`
+	TOTPPendingSecret  string     `json:"-"` // Generated by setup, becomes TOTPSecret once confirmed
+	TOTPLastStep       int64      `json:"-"` // Last accepted time step, prevents code replay
+	RecoveryCodeHashes []string   `json:"-"`
+	CreatedAt          time.Time  `json:"created_at" example:"2025-07-02T15:04:05Z"`
+	UpdatedAt          time.Time  `json:"updated_at" example:"2025-07-02T15:04:05Z"`
+	AnonymizedAt       *time.Time `json:"anonymized_at,omitempty" example:"2025-07-09T10:00:00Z"` // Personal data was erased, the record is kept for reporting
 }
 
 // LoginRequest represents the request payload for user login/authentication
@@ -50,18 +102,23 @@ type RegisterRequest struct {
 	Name        string `json:"name" binding:"required,min=2,max=100" example:"John Doe"`
 	Email       string `json:"email" binding:"required,email" example:"john@example.com"`
 	PhoneNumber string `json:"phone_number" binding:"required,min=10,max=15" example:"080-1234-5678"`
-	Type        string `json:"type" binding:"required,oneof=admin-staff organization jobseeker staffs" example:"jobseeker"`
+	Type        string `json:"type" binding:"required,oneof=jobseeker organization" example:"jobseeker"`
 	Password    string `json:"password" binding:"required,min=8,max=128" example:"securepassword123"`
 }
 
 // UserResponse represents the user data returned in API responses (without sensitive info)
 type UserResponse struct {
-	ID        int       `json:"id" example:"1"`
-	Name      string    `json:"name" example:"John Doe"`
-	Email     string    `json:"email" example:"john@example.com"`
-	Type      string    `json:"type" example:"jobseeker"`
-	CreatedAt time.Time `json:"created_at" example:"2025-07-02T15:04:05Z"`
-	UpdatedAt time.Time `json:"updated_at" example:"2025-07-02T15:04:05Z"`
+	ID               int        `json:"id" example:"1"`
+	Name             string     `json:"name" example:"John Doe"`
+	Email            string     `json:"email" example:"john@example.com"`
+	EmailVerified    bool       `json:"email_verified" example:"true"`
+	PendingEmail     string     `json:"pending_email,omitempty" example:"john.new@example.com"`
+	PhoneNumber      string     `json:"phone_number,omitempty" example:"080-1234-5678"`
+	Type             string     `json:"type" example:"jobseeker"`
+	TwoFactorEnabled bool       `json:"two_factor_enabled" example:"false"`
+	CreatedAt        time.Time  `json:"created_at" example:"2025-07-02T15:04:05Z"`
+	UpdatedAt        time.Time  `json:"updated_at" example:"2025-07-02T15:04:05Z"`
+	AnonymizedAt     *time.Time `json:"anonymized_at,omitempty" example:"2025-07-09T10:00:00Z"`
 }
 
 // ChangePasswordRequest represents the request payload for changing password
@@ -89,15 +146,59 @@ type PaginationInfo struct {
 // Helper method to convert User to UserResponse
 func (u *User) ToResponse() UserResponse {
 	return UserResponse{
-		ID:        u.ID,
-		Name:      u.Name,
-		Email:     u.Email,
-		Type:      u.Type,
-		CreatedAt: u.CreatedAt,
-		UpdatedAt: u.UpdatedAt,
+		ID:               u.ID,
+		Name:             u.Name,
+		Email:            u.Email,
+		EmailVerified:    u.EmailVerified,
+		PendingEmail:     u.PendingEmail,
+		PhoneNumber:      u.PhoneNumber,
+		Type:             u.Type,
+		TwoFactorEnabled: u.TwoFactorEnabled,
+		CreatedAt:        u.CreatedAt,
+		UpdatedAt:        u.UpdatedAt,
+		AnonymizedAt:     u.AnonymizedAt,
 	}
 }
 
+// SetPassword replaces the password hash and records it in the password
+// history, keeping at most historySize hashes
+func (u *User) SetPassword(hash string, historySize int) {
+	// Seed the history with the current hash for accounts created before
+	// password history was tracked
+	if len(u.PasswordHistory) == 0 && u.Password != "" {
+		u.PasswordHistory = []string{u.Password}
+	}
+	u.Password = hash
+	u.PasswordHistory = append([]string{hash}, u.PasswordHistory...)
+	if len(u.PasswordHistory) > historySize {
+		u.PasswordHistory = u.PasswordHistory[:historySize]
+	}
+}
+
+// Anonymize replaces the personal data and credentials of the user with
+// placeholders that cannot be traced back. The ID, type and timestamps are
+// kept so reports still add up.
+func (u *User) Anonymize(now time.Time) {
+	u.Name = "Anonymized user"
+	u.Email = fmt.Sprintf("anonymized-%d@anonymized.invalid", u.ID)
+	u.EmailVerified = false
+	u.PendingEmail = ""
+	u.PhoneNumber = ""
+	u.Password = ""
+	u.PasswordHistory = nil
+	u.TwoFactorEnabled = false
+	u.TOTPSecret = ""
+	u.TOTPPendingSecret = ""
+	u.TOTPLastStep = 0
+	u.RecoveryCodeHashes = nil
+	u.AnonymizedAt = &now
+}
+
+// IsAnonymized reports whether the personal data of the user was erased
+func (u *User) IsAnonymized() bool {
+	return u.AnonymizedAt != nil
+}
+
 // Helper methods for role checking
 func (u *User) IsViewer() bool       { return u.Type == UserTypeViewer }
 func (u *User) IsOperator() bool     { return u.Type == UserTypeOperator }
@@ -105,18 +206,14 @@ func (u *User) IsAdmin() bool        { return u.Type == UserTypeAdmin }
 func (u *User) IsOwner() bool        { return u.Type == UserTypeOwner }
 func (u *User) IsJobSeeker() bool    { return u.Type == UserTypeJobSeeker }
 func (u *User) IsOrganization() bool { return u.Type == UserTypeOrganization }
+func (u *User) IsService() bool      { return u.Type == UserTypeService }
+
+// IsStaff reports whether the user has an internal staff role
+func (u *User) IsStaff() bool {
+	return IsStaffType(u.Type)
+}
 
 // Check if user has administrative privileges
 func (u *User) HasAdminAccess() bool {
 	return u.Type == UserTypeAdmin || u.Type == UserTypeOwner
 }
-
-// Check if user can perform write operations
-func (u *User) CanWrite() bool {
-	return u.Type == UserTypeOperator || u.Type == UserTypeAdmin || u.Type == UserTypeOwner
-}
-
-// Check if user can only read
-func (u *User) IsReadOnly() bool {
-	return u.Type == UserTypeViewer
-}
@@ -0,0 +1,56 @@
+package models
+
+import "testing"
+
+func TestCanAssignRole(t *testing.T) {
+	tests := []struct {
+		caller, role string
+		want         bool
+	}{
+		{UserTypeOwner, UserTypeAdmin, true},
+		{UserTypeOwner, UserTypeViewer, true},
+		{UserTypeOwner, UserTypeOwner, false},
+		{UserTypeAdmin, UserTypeOperator, true},
+		{UserTypeAdmin, UserTypeAdmin, false},
+		{UserTypeAdmin, UserTypeOwner, false},
+		{UserTypeOperator, UserTypeViewer, true},
+		{UserTypeOperator, UserTypeOperator, false},
+		{UserTypeViewer, UserTypeViewer, false},
+		{UserTypeViewer, UserTypeJobSeeker, true},
+		// External and service accounts cannot assign anything
+		{UserTypeJobSeeker, UserTypeJobSeeker, false},
+		{UserTypeOrganization, UserTypeJobSeeker, false},
+		{UserTypeService, UserTypeJobSeeker, false},
+		{"", UserTypeJobSeeker, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.caller+" assigns "+tt.role, func(t *testing.T) {
+			if got := CanAssignRole(tt.caller, tt.role); got != tt.want {
+				t.Errorf("CanAssignRole(%q, %q) = %v, want %v", tt.caller, tt.role, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserTypeClasses(t *testing.T) {
+	tests := []struct {
+		userType        string
+		staff, external bool
+	}{
+		{UserTypeOwner, true, false},
+		{UserTypeAdmin, true, false},
+		{UserTypeOperator, true, false},
+		{UserTypeViewer, true, false},
+		{UserTypeJobSeeker, false, true},
+		{UserTypeOrganization, false, true},
+		{UserTypeService, false, false},
+	}
+	for _, tt := range tests {
+		if got := IsStaffType(tt.userType); got != tt.staff {
+			t.Errorf("IsStaffType(%q) = %v, want %v", tt.userType, got, tt.staff)
+		}
+		if got := IsExternalType(tt.userType); got != tt.external {
+			t.Errorf("IsExternalType(%q) = %v, want %v", tt.userType, got, tt.external)
+		}
+	}
+}
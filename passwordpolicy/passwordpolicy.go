@@ -0,0 +1,185 @@
+package passwordpolicy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hr-backend-system/config"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// minPersonalInfoLength ignores very short name parts such as initials,
+// which would otherwise reject too many passwords
+const minPersonalInfoLength = 3
+
+// Violation explains why a password was rejected
+type Violation struct {
+	Code    string
+	Message string
+}
+
+func (v *Violation) Error() string {
+	return v.Message
+}
+
+// Policy holds the active password rules
+type Policy struct {
+	MinLength          int  `json:"min_length" example:"8"`
+	RequireUpper       bool `json:"require_upper" example:"false"`
+	RequireLower       bool `json:"require_lower" example:"false"`
+	RequireDigit       bool `json:"require_digit" example:"false"`
+	RequireSymbol      bool `json:"require_symbol" example:"false"`
+	RejectPersonalInfo bool `json:"reject_personal_info" example:"true"`
+	CheckBreached      bool `json:"check_breached" example:"true"`
+}
+
+// Current returns the policy from config
+func Current() Policy {
+	cfg := config.Get()
+	return Policy{
+		MinLength:          cfg.PasswordMinLength,
+		RequireUpper:       cfg.PasswordRequireUpper,
+		RequireLower:       cfg.PasswordRequireLower,
+		RequireDigit:       cfg.PasswordRequireDigit,
+		RequireSymbol:      cfg.PasswordRequireSymbol,
+		RejectPersonalInfo: cfg.PasswordRejectPersonalInfo,
+		CheckBreached:      cfg.BreachedPasswordDir != "",
+	}
+}
+
+// Check validates password against the current policy. name and emails
+// are those of the account the password is for. It returns a *Violation if
+// the password is rejected.
+func Check(password, name string, emails ...string) error {
+	policy := Current()
+
+	if len([]rune(password)) < policy.MinLength {
+		return &Violation{"password_too_short", fmt.Sprintf("Password must be at least %d characters long", policy.MinLength)}
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if policy.RequireUpper && !hasUpper {
+		return &Violation{"password_missing_upper", "Password must contain an uppercase letter"}
+	}
+	if policy.RequireLower && !hasLower {
+		return &Violation{"password_missing_lower", "Password must contain a lowercase letter"}
+	}
+	if policy.RequireDigit && !hasDigit {
+		return &Violation{"password_missing_digit", "Password must contain a digit"}
+	}
+	if policy.RequireSymbol && !hasSymbol {
+		return &Violation{"password_missing_symbol", "Password must contain a symbol"}
+	}
+
+	if policy.RejectPersonalInfo && containsPersonalInfo(password, name, emails) {
+		return &Violation{"password_contains_personal_info", "Password must not contain your name or email address"}
+	}
+
+	if policy.CheckBreached {
+		breached, err := isBreached(password)
+		if err != nil {
+			// Fail open so a missing or unreadable list does not block all password changes
+			log.Printf("breached password check: %v", err)
+		} else if breached {
+			return &Violation{"password_breached", "This password has appeared in a data breach, please choose another one"}
+		}
+	}
+
+	return nil
+}
+
+// AsViolation returns the violation wrapped in err, if any
+func AsViolation(err error) (*Violation, bool) {
+	var v *Violation
+	ok := errors.As(err, &v)
+	return v, ok
+}
+
+// containsPersonalInfo reports whether password contains the name, a part
+// of the name, an email address or its local part, ignoring case
+func containsPersonalInfo(password, name string, emails []string) bool {
+	lower := strings.ToLower(password)
+
+	candidates := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	candidates = append(candidates, strings.Join(candidates, ""))
+
+	for _, email := range emails {
+		email = strings.ToLower(strings.TrimSpace(email))
+		if local, _, found := strings.Cut(email, "@"); found {
+			candidates = append(candidates, email, local)
+		}
+	}
+
+	for _, candidate := range candidates {
+		if len([]rune(candidate)) >= minPersonalInfoLength && strings.Contains(lower, candidate) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBreached looks the password up in the breached password list. Like the
+// Have I Been Pwned range API, the list is split into files named after the
+// first five hex characters of the SHA-1 hash. Each line holds the remaining
+// 35 characters and a count, e.g. "0018A45C4D1DEF81644B54AB7F969B88D65:10".
+func isBreached(password string) (bool, error) {
+	cfg := config.Get()
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:5], hash[5:]
+
+	file, err := openPrefixFile(cfg.BreachedPasswordDir, prefix)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineSuffix, countText, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if !strings.EqualFold(lineSuffix, suffix) {
+			continue
+		}
+		count, err := strconv.Atoi(countText)
+		if err != nil {
+			// Lists without counts only contain breached hashes
+			count = 1
+		}
+		return count >= cfg.BreachedPasswordMinCount, nil
+	}
+	return false, scanner.Err()
+}
+
+// openPrefixFile opens the file for a hash prefix, with or without a .txt extension
+func openPrefixFile(dir, prefix string) (*os.File, error) {
+	file, err := os.Open(filepath.Join(dir, prefix))
+	if errors.Is(err, os.ErrNotExist) {
+		return os.Open(filepath.Join(dir, prefix+".txt"))
+	}
+	return file, err
+}
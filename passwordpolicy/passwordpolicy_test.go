@@ -0,0 +1,88 @@
+package passwordpolicy
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"hr-backend-system/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// breachedList writes a breached password list holding password with count
+// and returns its directory
+func breachedList(t *testing.T, password, count string) string {
+	t.Helper()
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	dir := t.TempDir()
+	content := "0018A45C4D1DEF81644B54AB7F969B88D65:10\n" + strings.ToLower(hash[5:]) + ":" + count + "\n"
+	if err := os.WriteFile(filepath.Join(dir, hash[:5]+".txt"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestCheck(t *testing.T) {
+	cfg := config.Get()
+	saved := *cfg
+	defer func() { *cfg = saved }()
+
+	strict := func(c *config.Config) {
+		c.PasswordMinLength = 10
+		c.PasswordRequireUpper = true
+		c.PasswordRequireLower = true
+		c.PasswordRequireDigit = true
+		c.PasswordRequireSymbol = true
+	}
+	tests := []struct {
+		name      string
+		configure func(c *config.Config)
+		password  string
+		wantCode  string
+	}{
+		{"long enough", nil, "correcthorse", ""},
+		{"too short", nil, "short1", "password_too_short"},
+		{"counts characters, not bytes", func(c *config.Config) { c.PasswordMinLength = 4 }, "ñäöü", ""},
+		{"missing upper", strict, "correct-horse-42", "password_missing_upper"},
+		{"missing lower", strict, "CORRECT-HORSE-42", "password_missing_lower"},
+		{"missing digit", strict, "Correct-Horse-X", "password_missing_digit"},
+		{"missing symbol", strict, "CorrectHorse42", "password_missing_symbol"},
+		{"all classes", strict, "Correct-Horse-42", ""},
+		{"contains the name", nil, "xxAliceSmith99", "password_contains_personal_info"},
+		{"contains a name part", nil, "smith-is-strong", "password_contains_personal_info"},
+		{"contains the email local part", nil, "my-asmith-pass", "password_contains_personal_info"},
+		{"personal info allowed", func(c *config.Config) { c.PasswordRejectPersonalInfo = false }, "alice-smith-1", ""},
+		{"breached", func(c *config.Config) { c.BreachedPasswordDir = breachedList(t, "breached-pass", "3") }, "breached-pass", "password_breached"},
+		{"breached less than the minimum", func(c *config.Config) {
+			c.BreachedPasswordDir = breachedList(t, "breached-pass", "3")
+			c.BreachedPasswordMinCount = 5
+		}, "breached-pass", ""},
+		{"not in the list", func(c *config.Config) { c.BreachedPasswordDir = breachedList(t, "breached-pass", "3") }, "unbreached-pass", ""},
+		{"missing list fails open", func(c *config.Config) { c.BreachedPasswordDir = filepath.Join(t.TempDir(), "missing") }, "breached-pass", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*cfg = saved
+			cfg.PasswordMinLength = 8
+			cfg.PasswordRejectPersonalInfo = true
+			cfg.BreachedPasswordDir = ""
+			cfg.BreachedPasswordMinCount = 1
+			if tt.configure != nil {
+				tt.configure(cfg)
+			}
+
+			err := Check(tt.password, "Alice Smith", "asmith@example.com")
+			code := ""
+			if violation, ok := AsViolation(err); ok {
+				code = violation.Code
+			} else if err != nil {
+				t.Fatalf("Check() = %v, want a violation", err)
+			}
+			if code != tt.wantCode {
+				t.Errorf("Check(%q) = %q, want %q", tt.password, code, tt.wantCode)
+			}
+		})
+	}
+}
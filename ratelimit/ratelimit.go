@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"hr-backend-system/config"
+	"hr-backend-system/storage"
+	"math"
+	"time"
+)
+
+// Result is the outcome of Take
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration // Until the next request is allowed, zero when allowed
+	ResetAfter time.Duration // Until the bucket is full again
+}
+
+// Take spends one request of the quota for key. Callers get limit.Requests
+// requests per limit.Period, refilled continuously, so bursts of up to
+// limit.Requests are possible after a quiet period.
+func Take(key string, limit config.RateLimit) Result {
+	if limit.Requests < 1 || limit.Period <= 0 {
+		return Result{Allowed: true}
+	}
+
+	rate := float64(limit.Requests) / limit.Period.Seconds()
+	bucket, allowed := storage.RateLimits().TakeToken(key, rate, limit.Requests, time.Now())
+
+	result := Result{
+		Allowed:    allowed,
+		Limit:      limit.Requests,
+		Remaining:  int(math.Floor(bucket.Tokens)),
+		ResetAfter: secondsToDuration((float64(limit.Requests) - bucket.Tokens) / rate),
+	}
+	if !allowed {
+		result.RetryAfter = secondsToDuration((1 - bucket.Tokens) / rate)
+	}
+	return result
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
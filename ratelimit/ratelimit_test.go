@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"hr-backend-system/config"
+	"testing"
+	"time"
+)
+
+func TestTake(t *testing.T) {
+	limit := config.RateLimit{Requests: 2, Period: time.Hour}
+
+	tests := []struct {
+		name          string
+		wantAllowed   bool
+		wantRemaining int
+	}{
+		{"first request", true, 1},
+		{"second request", true, 0},
+		{"over the limit", false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Take("test:take", limit)
+			if result.Allowed != tt.wantAllowed {
+				t.Errorf("Allowed = %v, want %v", result.Allowed, tt.wantAllowed)
+			}
+			if result.Remaining != tt.wantRemaining {
+				t.Errorf("Remaining = %d, want %d", result.Remaining, tt.wantRemaining)
+			}
+			if result.Limit != limit.Requests {
+				t.Errorf("Limit = %d, want %d", result.Limit, limit.Requests)
+			}
+			if tt.wantAllowed != (result.RetryAfter == 0) {
+				t.Errorf("RetryAfter = %s with Allowed = %v", result.RetryAfter, result.Allowed)
+			}
+			if result.ResetAfter <= 0 || result.ResetAfter > limit.Period {
+				t.Errorf("ResetAfter = %s, want within (0, %s]", result.ResetAfter, limit.Period)
+			}
+		})
+	}
+}
+
+func TestTakeRetryAfter(t *testing.T) {
+	limit := config.RateLimit{Requests: 1, Period: time.Minute}
+	Take("test:retry", limit)
+
+	// One request per minute, so the next is about a minute away
+	result := Take("test:retry", limit)
+	if result.Allowed {
+		t.Fatal("second request allowed")
+	}
+	if result.RetryAfter < 59*time.Second || result.RetryAfter > time.Minute {
+		t.Errorf("RetryAfter = %s, want about a minute", result.RetryAfter)
+	}
+}
+
+func TestTakeDisabled(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit config.RateLimit
+	}{
+		{"no requests", config.RateLimit{Requests: 0, Period: time.Minute}},
+		{"no period", config.RateLimit{Requests: 1, Period: 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 5; i++ {
+				if result := Take("test:disabled", tt.limit); !result.Allowed {
+					t.Fatalf("request %d refused by a disabled limit", i+1)
+				}
+			}
+		})
+	}
+}
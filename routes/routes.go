@@ -12,7 +12,10 @@ import (
 // SetupRoutes configures all routes
 func SetupRoutes(router *gin.Engine) {
 	// Middleware
+	router.Use(middleware.RequestID())
+	router.Use(middleware.SecurityHeaders())
 	router.Use(middleware.SetupCORS())
+	router.Use(middleware.CSRFProtection())
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
 
@@ -24,9 +27,10 @@ func SetupRoutes(router *gin.Engine) {
 			Data: gin.H{
 				"version": "1.0.0",
 				"endpoints": gin.H{
-					"health": "/api/v1/health",
-					"users":  "/api/v1/users",
-					"auth":   "/api/v1/auth/login",
+					"health":   "/api/v1/health",
+					"users":    "/api/v1/users",
+					"auth":     "/api/v1/auth/login",
+					"register": "/api/v1/auth/register",
 				},
 			},
 		})
@@ -38,14 +42,126 @@ func SetupRoutes(router *gin.Engine) {
 		// Health check
 		api.GET("/health", handlers.HealthCheck)
 
+		// Auth routes
+		auth := api.Group("/auth")
+		auth.Use(middleware.RateLimit("auth"))
+		{
+			auth.POST("/register", handlers.Register)
+			auth.POST("/login", handlers.Login)
+			auth.POST("/2fa/verify", handlers.VerifyTwoFactorLogin)
+			auth.POST("/refresh", handlers.RefreshToken)
+			auth.POST("/logout", handlers.Logout)
+			auth.GET("/password-policy", handlers.GetPasswordPolicy)
+			auth.POST("/forgot-password", handlers.ForgotPassword)
+			auth.POST("/reset-password", handlers.ResetPassword)
+			auth.POST("/verify-email", handlers.VerifyEmail)
+			auth.POST("/resend-verification", handlers.ResendVerification)
+			auth.GET("/oidc/providers", handlers.GetOIDCProviders)
+			auth.GET("/oidc/:provider/login", handlers.OIDCLogin)
+			auth.GET("/oidc/:provider/callback", handlers.OIDCCallback)
+		}
+
 		// User routes
 		users := api.Group("/users")
+		users.Use(middleware.RateLimitIP(), middleware.AuthRequired(), middleware.RateLimit("users"))
+		{
+			// Self-service routes, available to every authenticated user
+			users.POST("/me/password", middleware.BlockImpersonation(), handlers.ChangePassword)
+			users.GET("/me/sessions", handlers.GetMySessions)
+			users.DELETE("/me/sessions", middleware.BlockImpersonation(), handlers.RevokeMyOtherSessions)
+			users.DELETE("/me/sessions/:sessionId", middleware.BlockImpersonation(), handlers.RevokeMySession)
+
+			users.GET("", middleware.RequirePermission(models.PermUsersRead), handlers.GetUsers)
+			users.POST("", middleware.RequirePermission(models.PermUsersCreate), handlers.CreateUser)
+			users.GET("/:id", middleware.RequirePermission(models.PermUsersRead), handlers.GetUserByID)
+			users.PUT("/:id", middleware.RequirePermission(models.PermUsersUpdate), handlers.UpdateUser)
+			users.DELETE("/:id", middleware.BlockImpersonation(), middleware.RequirePermission(models.PermUsersDelete), handlers.DeleteUser)
+			users.POST("/:id/anonymize", middleware.BlockImpersonation(), middleware.RequirePermission(models.PermUsersDelete), handlers.AnonymizeUser)
+			users.POST("/:id/unlock", middleware.RequirePermission(models.PermUsersUnlock), handlers.UnlockUser)
+			users.GET("/:id/export", middleware.BlockImpersonation(), handlers.ExportUser)
+			users.GET("/:id/exports/:exportId", middleware.BlockImpersonation(), handlers.GetUserExport)
+			users.GET("/:id/exports/:exportId/download", middleware.BlockImpersonation(), handlers.DownloadUserExport)
+			users.POST("/:id/impersonate", middleware.BlockImpersonation(), middleware.RequirePermission(models.PermUsersImpersonate), handlers.StartImpersonation)
+			users.GET("/:id/sessions", middleware.RequirePermission(models.PermSessionsManage), handlers.GetUserSessions)
+			users.DELETE("/:id/sessions", middleware.RequirePermission(models.PermSessionsManage), handlers.RevokeAllUserSessions)
+			users.DELETE("/:id/sessions/:sessionId", middleware.RequirePermission(models.PermSessionsManage), handlers.RevokeUserSession)
+		}
+
+		// Two-factor enrollment, reachable before setup is complete
+		twoFactor := api.Group("/users/me/2fa")
+		twoFactor.Use(middleware.RateLimitIP(), middleware.AuthRequiredForTwoFactorSetup(), middleware.BlockImpersonation(), middleware.RateLimit("auth"))
+		{
+			twoFactor.POST("/setup", handlers.SetupTwoFactor)
+			twoFactor.POST("/enable", handlers.EnableTwoFactor)
+			twoFactor.POST("/disable", handlers.DisableTwoFactor)
+			twoFactor.POST("/recovery-codes", handlers.RegenerateRecoveryCodes)
+		}
+
+		// Ownership transfer routes, offers are made by owners and
+		// accepted or declined by the recipient
+		ownership := api.Group("/ownership/transfers")
+		ownership.Use(middleware.RateLimitIP(), middleware.AuthRequired(), middleware.BlockImpersonation(), middleware.RateLimit("ownership"))
+		{
+			ownership.GET("", handlers.GetOwnershipTransfers)
+			ownership.POST("", middleware.RequireOwner(), handlers.StartOwnershipTransfer)
+			ownership.DELETE("/:id", handlers.CancelOwnershipTransfer)
+			ownership.POST("/:id/accept", handlers.AcceptOwnershipTransfer)
+			ownership.POST("/:id/decline", handlers.DeclineOwnershipTransfer)
+		}
+
+		// Role change requests, external accounts only become staff
+		// once an owner approves
+		roleRequests := api.Group("/role-requests")
+		roleRequests.Use(middleware.RateLimitIP(), middleware.AuthRequired(), middleware.RateLimit("roles"))
+		{
+			roleRequests.GET("", middleware.RequirePermission(models.PermRolesRead), handlers.GetRoleChangeRequests)
+			roleRequests.POST("", middleware.RequirePermission(models.PermRoleRequestsCreate), handlers.CreateRoleChangeRequest)
+			roleRequests.POST("/:id/approve", middleware.RequireOwner(), handlers.ApproveRoleChangeRequest)
+			roleRequests.POST("/:id/reject", middleware.RequireOwner(), handlers.RejectRoleChangeRequest)
+		}
+
+		// Service account routes
+		serviceAccounts := api.Group("/service-accounts")
+		serviceAccounts.Use(middleware.RateLimitIP(), middleware.AuthRequired(), middleware.RateLimit("service_accounts"), middleware.RequirePermission(models.PermServiceAccountsManage))
+		{
+			serviceAccounts.GET("", handlers.GetServiceAccounts)
+			serviceAccounts.POST("", handlers.CreateServiceAccount)
+			serviceAccounts.GET("/:id/keys", handlers.GetAPIKeys)
+			serviceAccounts.POST("/:id/keys", handlers.CreateAPIKey)
+			serviceAccounts.DELETE("/:id/keys/:keyId", handlers.RevokeAPIKey)
+		}
+
+		// Security routes
+		security := api.Group("/security")
+		security.Use(middleware.RateLimitIP(), middleware.AuthRequired(), middleware.RateLimit("security"))
+		{
+			security.GET("/events", middleware.RequirePermission(models.PermSecurityRead), handlers.GetSecurityEvents)
+			security.POST("/unlock-ip", middleware.RequirePermission(models.PermSecurityManage), handlers.UnlockIP)
+			security.POST("/encryption-key/rotate", middleware.RequirePermission(models.PermSecurityManage), handlers.RotateEncryptionKey)
+		}
+
+		// Impersonation routes, admins end an impersonation with its token
+		api.DELETE("/impersonation", middleware.RateLimitIP(), middleware.AuthRequired(), middleware.RateLimit("users"), handlers.StopImpersonation)
+
+		// Audit log
+		api.GET("/audit", middleware.RateLimitIP(), middleware.AuthRequired(), middleware.RateLimit("audit"), middleware.RequirePermission(models.PermAuditRead), handlers.GetAuditLog)
+
+		// Settings routes
+		settings := api.Group("/settings")
+		settings.Use(middleware.RateLimitIP(), middleware.AuthRequired(), middleware.RateLimit("settings"))
+		{
+			settings.GET("", middleware.RequirePermission(models.PermSettingsRead), handlers.GetSettings)
+			settings.PUT("", middleware.RequirePermission(models.PermSettingsUpdate), handlers.UpdateSettings)
+		}
+
+		// Role and permission routes, role mappings are edited by owners
+		api.GET("/permissions", middleware.RateLimitIP(), middleware.AuthRequired(), middleware.RateLimit("roles"), middleware.RequirePermission(models.PermRolesRead), handlers.GetPermissions)
+		roles := api.Group("/roles")
+		roles.Use(middleware.RateLimitIP(), middleware.AuthRequired(), middleware.RateLimit("roles"))
 		{
-			users.GET("", handlers.GetUsers)
-			users.POST("", handlers.CreateUser)
-			users.GET("/:id", handlers.GetUserByID)
-			users.PUT("/:id", handlers.UpdateUser)
-			users.DELETE("/:id", handlers.DeleteUser)
+			roles.GET("", middleware.RequirePermission(models.PermRolesRead), handlers.GetRoles)
+			roles.GET("/:name", middleware.RequirePermission(models.PermRolesRead), handlers.GetRole)
+			roles.PUT("/:name", middleware.RequireOwner(), handlers.UpdateRole)
 		}
 	}
 }
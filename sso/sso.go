@@ -0,0 +1,122 @@
+package sso
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hr-backend-system/config"
+	"sync"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// ErrUnknownProvider is returned for provider names that are not configured
+var ErrUnknownProvider = errors.New("unknown login provider")
+
+// Identity is the verified information a provider returned about a user
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Client talks to a single OpenID Connect provider
+type Client struct {
+	provider config.OIDCProvider
+	oauth    oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+var (
+	clients  = map[string]*Client{}
+	clientMu sync.Mutex
+)
+
+// Providers returns the configured login providers
+func Providers() []config.OIDCProvider {
+	return config.Get().OIDCProviders
+}
+
+// Get returns the client for a configured provider. The provider's discovery
+// document is fetched on first use, so the server starts even if a provider
+// is unreachable and failed lookups are retried on the next login.
+func Get(ctx context.Context, name string) (*Client, error) {
+	clientMu.Lock()
+	defer clientMu.Unlock()
+
+	if client, exists := clients[name]; exists {
+		return client, nil
+	}
+
+	for _, p := range Providers() {
+		if p.Name != name {
+			continue
+		}
+
+		discovered, err := oidc.NewProvider(ctx, p.IssuerURL)
+		if err != nil {
+			return nil, fmt.Errorf("discover %s: %w", p.Name, err)
+		}
+
+		client := &Client{
+			provider: p,
+			oauth: oauth2.Config{
+				ClientID:     p.ClientID,
+				ClientSecret: p.ClientSecret,
+				Endpoint:     discovered.Endpoint(),
+				RedirectURL:  p.RedirectURL,
+				Scopes:       p.Scopes,
+			},
+			verifier: discovered.Verifier(&oidc.Config{ClientID: p.ClientID}),
+		}
+		clients[name] = client
+		return client, nil
+	}
+	return nil, ErrUnknownProvider
+}
+
+// AuthCodeURL returns the provider URL to send the user to. The code
+// challenge is derived from verifier using S256.
+func (c *Client) AuthCodeURL(state, nonce, verifier string) string {
+	return c.oauth.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+}
+
+// Exchange redeems an authorization code and verifies the returned ID token,
+// including its audience, expiry and nonce
+func (c *Client) Exchange(ctx context.Context, code, verifier, nonce string) (Identity, error) {
+	token, err := c.oauth.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return Identity{}, fmt.Errorf("exchange code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return Identity{}, errors.New("token response has no id_token")
+	}
+
+	idToken, err := c.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return Identity{}, fmt.Errorf("verify id_token: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return Identity{}, errors.New("id_token nonce does not match")
+	}
+
+	var claims struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		Name          string `json:"name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return Identity{}, fmt.Errorf("read id_token claims: %w", err)
+	}
+
+	return Identity{
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+	}, nil
+}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"hr-backend-system/models"
+	"sync"
+	"time"
+)
+
+var (
+	apiKeys       []models.APIKey
+	apiKeyCounter int = 1
+	apiKeyMu      sync.RWMutex
+)
+
+// AddAPIKey stores a new API key and returns it with its assigned ID
+func AddAPIKey(key models.APIKey) models.APIKey {
+	apiKeyMu.Lock()
+	defer apiKeyMu.Unlock()
+	key.ID = apiKeyCounter
+	apiKeyCounter++
+	apiKeys = append(apiKeys, key)
+	return key
+}
+
+// GetAPIKeyByHash returns an API key by the hash of its plaintext value
+func GetAPIKeyByHash(keyHash string) (models.APIKey, bool) {
+	apiKeyMu.RLock()
+	defer apiKeyMu.RUnlock()
+	for _, key := range apiKeys {
+		if key.KeyHash == keyHash {
+			return key, true
+		}
+	}
+	return models.APIKey{}, false
+}
+
+// GetAPIKeysByUser returns all API keys of a user, including revoked ones
+func GetAPIKeysByUser(userID int) []models.APIKey {
+	apiKeyMu.RLock()
+	defer apiKeyMu.RUnlock()
+	result := []models.APIKey{}
+	for _, key := range apiKeys {
+		if key.UserID == userID {
+			result = append(result, key)
+		}
+	}
+	return result
+}
+
+// RevokeAPIKey revokes an API key of a user
+func RevokeAPIKey(userID, id int) (models.APIKey, bool) {
+	apiKeyMu.Lock()
+	defer apiKeyMu.Unlock()
+	for i, key := range apiKeys {
+		if key.ID == id && key.UserID == userID {
+			if key.RevokedAt == nil {
+				now := time.Now()
+				apiKeys[i].RevokedAt = &now
+			}
+			return apiKeys[i], true
+		}
+	}
+	return models.APIKey{}, false
+}
+
+// TouchAPIKey records that an API key was just used
+func TouchAPIKey(id int) {
+	apiKeyMu.Lock()
+	defer apiKeyMu.Unlock()
+	for i, key := range apiKeys {
+		if key.ID == id {
+			now := time.Now()
+			apiKeys[i].LastUsedAt = &now
+			return
+		}
+	}
+}
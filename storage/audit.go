@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"hr-backend-system/models"
+	"os"
+	"sync"
+	"time"
+)
+
+// memoryAuditStore is the in-memory AuditStore, entries are lost on restart
+type memoryAuditStore struct {
+	mu      sync.RWMutex
+	entries []models.AuditEntry
+	nextID  int
+}
+
+func newMemoryAuditStore() *memoryAuditStore {
+	return &memoryAuditStore{nextID: 1}
+}
+
+func (s *memoryAuditStore) AppendAuditEntry(entry models.AuditEntry) (models.AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.appendLocked(&entry)
+	return entry, nil
+}
+
+func (s *memoryAuditStore) appendLocked(entry *models.AuditEntry) {
+	entry.ID = s.nextID
+	s.nextID++
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	s.entries = append(s.entries, *entry)
+}
+
+func (s *memoryAuditStore) ListAuditEntries(filter models.AuditFilter) []models.AuditEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := []models.AuditEntry{}
+	for i := len(s.entries) - 1; i >= 0 && (filter.Limit <= 0 || len(result) < filter.Limit); i-- {
+		if filter.Matches(&s.entries[i]) {
+			result = append(result, s.entries[i])
+		}
+	}
+	return result
+}
+
+// fileAuditStore appends entries to a JSON lines file and keeps them in
+// memory for queries
+type fileAuditStore struct {
+	memoryAuditStore
+	file *os.File
+}
+
+// NewFileAuditStore opens the JSON lines audit log at path, creating it if
+// needed, and loads the existing entries. The file is only ever appended to.
+func NewFileAuditStore(path string) (AuditStore, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+
+	store := &fileAuditStore{memoryAuditStore: memoryAuditStore{nextID: 1}, file: file}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var entry models.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("read audit log line %d: %w", line, err)
+		}
+		store.entries = append(store.entries, entry)
+		if entry.ID >= store.nextID {
+			store.nextID = entry.ID + 1
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("read audit log: %w", err)
+	}
+	return store, nil
+}
+
+func (s *fileAuditStore) AppendAuditEntry(entry models.AuditEntry) (models.AuditEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Write before keeping the entry so the log never misses one that was served
+	next := entry
+	next.ID = s.nextID
+	if next.CreatedAt.IsZero() {
+		next.CreatedAt = time.Now()
+	}
+	line, err := json.Marshal(next)
+	if err != nil {
+		return models.AuditEntry{}, fmt.Errorf("encode audit entry: %w", err)
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return models.AuditEntry{}, fmt.Errorf("write audit entry: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return models.AuditEntry{}, fmt.Errorf("sync audit log: %w", err)
+	}
+
+	s.appendLocked(&next)
+	return next, nil
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"hr-backend-system/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	dataExports  = map[string]models.DataExport{}
+	dataExportMu sync.Mutex
+)
+
+// AddDataExport stores a new data export
+func AddDataExport(export models.DataExport) {
+	dataExportMu.Lock()
+	defer dataExportMu.Unlock()
+	dataExports[export.ID] = export
+}
+
+// GetDataExport returns a data export by ID
+func GetDataExport(id string) (models.DataExport, bool) {
+	dataExportMu.Lock()
+	defer dataExportMu.Unlock()
+	export, exists := dataExports[id]
+	return export, exists
+}
+
+// GetUserDataExports returns the unexpired exports of a user, newest first
+func GetUserDataExports(userID int) []models.DataExport {
+	dataExportMu.Lock()
+	defer dataExportMu.Unlock()
+	now := time.Now()
+	result := []models.DataExport{}
+	for _, export := range dataExports {
+		if export.UserID == userID && now.Before(export.ExpiresAt) {
+			result = append(result, export)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	return result
+}
+
+// UpdateDataExport replaces a stored data export. It returns false if the
+// export was removed in the meantime.
+func UpdateDataExport(export models.DataExport) bool {
+	dataExportMu.Lock()
+	defer dataExportMu.Unlock()
+	if _, exists := dataExports[export.ID]; !exists {
+		return false
+	}
+	dataExports[export.ID] = export
+	return true
+}
+
+// RemoveUserDataExports drops every export of a user and returns them so
+// their files can be deleted
+func RemoveUserDataExports(userID int) []models.DataExport {
+	dataExportMu.Lock()
+	defer dataExportMu.Unlock()
+	var removed []models.DataExport
+	for id, export := range dataExports {
+		if export.UserID == userID {
+			removed = append(removed, export)
+			delete(dataExports, id)
+		}
+	}
+	return removed
+}
+
+// RemoveExpiredDataExports drops expired exports and returns them so their
+// files can be deleted
+func RemoveExpiredDataExports(now time.Time) []models.DataExport {
+	dataExportMu.Lock()
+	defer dataExportMu.Unlock()
+	var removed []models.DataExport
+	for id, export := range dataExports {
+		if !now.Before(export.ExpiresAt) {
+			removed = append(removed, export)
+			delete(dataExports, id)
+		}
+	}
+	return removed
+}
@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"hr-backend-system/models"
+	"time"
+)
+
+// LoginAttemptStore tracks failed logins per account and per IP address.
+// Implementations must be safe for concurrent use.
+type LoginAttemptStore interface {
+	// GetLoginAttempt returns the tracked failures for key
+	GetLoginAttempt(key string) (models.LoginAttempt, bool)
+	// RecordLoginFailure counts a failure for key and returns the updated
+	// record. The count starts over when the last failure is older than window.
+	RecordLoginFailure(key string, window time.Duration) models.LoginAttempt
+	// LockLogin blocks key until the given time
+	LockLogin(key string, until time.Time)
+	// ClearLoginAttempts forgets the failures and any lockout for key
+	ClearLoginAttempts(key string)
+}
+
+// SecurityEventStore is an append-only log of security events.
+// Implementations must be safe for concurrent use.
+type SecurityEventStore interface {
+	AddSecurityEvent(event models.SecurityEvent) models.SecurityEvent
+	// ListSecurityEvents returns events newest first, optionally filtered by type
+	ListSecurityEvents(eventType string, limit int) []models.SecurityEvent
+}
+
+// RateLimitStore keeps the token buckets of the rate limiter. A shared
+// implementation, e.g. backed by Redis, lets several API instances enforce
+// the same quotas. Implementations must be safe for concurrent use.
+type RateLimitStore interface {
+	// TakeToken refills the bucket for key at rate tokens per second, up to
+	// burst tokens, then removes one token if there is one. It returns the
+	// bucket after the update and whether a token was taken.
+	TakeToken(key string, rate float64, burst int, now time.Time) (models.TokenBucket, bool)
+}
+
+// AuditStore is an append-only log of user mutations. Implementations
+// must be safe for concurrent use and must not offer a way to change or
+// remove entries.
+type AuditStore interface {
+	// AppendAuditEntry assigns the entry an ID and stores it
+	AppendAuditEntry(entry models.AuditEntry) (models.AuditEntry, error)
+	// ListAuditEntries returns matching entries newest first
+	ListAuditEntries(filter models.AuditFilter) []models.AuditEntry
+}
+
+// SubjectKeyStore keeps the per-user keys the audit log encrypts personal
+// data with. Shredding a user's key leaves that data unreadable in every
+// entry and copy of the log. Implementations must be safe for concurrent use.
+type SubjectKeyStore interface {
+	// GetSubjectKey returns the sealed key of a user, or erased when the
+	// key was shredded
+	GetSubjectKey(userID int) (key string, erased bool)
+	// AddSubjectKey stores key unless the user already has one or was
+	// erased, and returns the key in effect, empty for erased users
+	AddSubjectKey(userID int, key string) (string, error)
+	// ShredSubjectKey deletes the key of a user for good
+	ShredSubjectKey(userID int) error
+}
+
+var (
+	loginAttemptStore  LoginAttemptStore  = newMemoryLoginAttemptStore()
+	securityEventStore SecurityEventStore = newMemorySecurityEventStore()
+	rateLimitStore     RateLimitStore     = newMemoryRateLimitStore()
+	auditStore         AuditStore         = newMemoryAuditStore()
+	subjectKeyStore    SubjectKeyStore    = newMemorySubjectKeyStore()
+)
+
+// LoginAttempts returns the active login attempt store
+func LoginAttempts() LoginAttemptStore {
+	return loginAttemptStore
+}
+
+// SetLoginAttemptStore replaces the login attempt store, e.g. with a database backed one
+func SetLoginAttemptStore(store LoginAttemptStore) {
+	loginAttemptStore = store
+}
+
+// SecurityEvents returns the active security event store
+func SecurityEvents() SecurityEventStore {
+	return securityEventStore
+}
+
+// SetSecurityEventStore replaces the security event store
+func SetSecurityEventStore(store SecurityEventStore) {
+	securityEventStore = store
+}
+
+// RateLimits returns the active rate limit store
+func RateLimits() RateLimitStore {
+	return rateLimitStore
+}
+
+// SetRateLimitStore replaces the rate limit store, e.g. with a shared one
+func SetRateLimitStore(store RateLimitStore) {
+	rateLimitStore = store
+}
+
+// Audit returns the active audit store
+func Audit() AuditStore {
+	return auditStore
+}
+
+// SetAuditStore replaces the audit store, e.g. with NewFileAuditStore
+func SetAuditStore(store AuditStore) {
+	auditStore = store
+}
+
+// SubjectKeys returns the active subject key store
+func SubjectKeys() SubjectKeyStore {
+	return subjectKeyStore
+}
+
+// SetSubjectKeyStore replaces the subject key store, e.g. with
+// NewFileSubjectKeyStore
+func SetSubjectKeyStore(store SubjectKeyStore) {
+	subjectKeyStore = store
+}
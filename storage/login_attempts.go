@@ -0,0 +1,129 @@
+package storage
+
+import (
+	"hr-backend-system/models"
+	"sync"
+	"time"
+)
+
+// loginAttemptSweepInterval is how often forgotten attempts are dropped
+const loginAttemptSweepInterval = time.Minute
+
+// memoryLoginAttemptStore is the in-memory LoginAttemptStore
+type memoryLoginAttemptStore struct {
+	mu        sync.Mutex
+	attempts  map[string]memoryLoginAttempt
+	nextSweep time.Time
+}
+
+// memoryLoginAttempt remembers when the failures are forgotten and any
+// lockout is over, after which the record can be dropped
+type memoryLoginAttempt struct {
+	models.LoginAttempt
+	expiresAt time.Time
+}
+
+func newMemoryLoginAttemptStore() *memoryLoginAttemptStore {
+	return &memoryLoginAttemptStore{attempts: map[string]memoryLoginAttempt{}}
+}
+
+func (s *memoryLoginAttemptStore) GetLoginAttempt(key string) (models.LoginAttempt, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	attempt, exists := s.attempts[key]
+	return attempt.LoginAttempt, exists
+}
+
+func (s *memoryLoginAttemptStore) RecordLoginFailure(key string, window time.Duration) models.LoginAttempt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	s.sweepLocked(now)
+
+	stored, exists := s.attempts[key]
+	attempt := stored.LoginAttempt
+	if !exists || now.Sub(attempt.LastFailureAt) > window {
+		attempt = models.LoginAttempt{Key: key, FirstFailureAt: now, LockedUntil: attempt.LockedUntil}
+	}
+	attempt.Failures++
+	attempt.LastFailureAt = now
+	s.attempts[key] = memoryLoginAttempt{
+		LoginAttempt: attempt,
+		expiresAt:    latest(now.Add(window), attempt.LockedUntil),
+	}
+	return attempt
+}
+
+func (s *memoryLoginAttemptStore) LockLogin(key string, until time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweepLocked(time.Now())
+
+	stored := s.attempts[key]
+	stored.Key = key
+	stored.LockedUntil = until
+	stored.expiresAt = latest(stored.expiresAt, until)
+	s.attempts[key] = stored
+}
+
+func (s *memoryLoginAttemptStore) ClearLoginAttempts(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attempts, key)
+}
+
+// sweepLocked drops records whose failures and lockout have expired, so
+// attempts with made-up emails do not pile up. s.mu must be held.
+func (s *memoryLoginAttemptStore) sweepLocked(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	for key, attempt := range s.attempts {
+		if now.After(attempt.expiresAt) {
+			delete(s.attempts, key)
+		}
+	}
+	s.nextSweep = now.Add(loginAttemptSweepInterval)
+}
+
+func latest(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// memorySecurityEventStore is the in-memory SecurityEventStore
+type memorySecurityEventStore struct {
+	mu     sync.RWMutex
+	events []models.SecurityEvent
+	nextID int
+}
+
+func newMemorySecurityEventStore() *memorySecurityEventStore {
+	return &memorySecurityEventStore{nextID: 1}
+}
+
+func (s *memorySecurityEventStore) AddSecurityEvent(event models.SecurityEvent) models.SecurityEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event.ID = s.nextID
+	s.nextID++
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	s.events = append(s.events, event)
+	return event
+}
+
+func (s *memorySecurityEventStore) ListSecurityEvents(eventType string, limit int) []models.SecurityEvent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	result := []models.SecurityEvent{}
+	for i := len(s.events) - 1; i >= 0 && len(result) < limit; i-- {
+		if eventType == "" || s.events[i].Type == eventType {
+			result = append(result, s.events[i])
+		}
+	}
+	return result
+}
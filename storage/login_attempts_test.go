@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoginAttemptWindow(t *testing.T) {
+	store := newMemoryLoginAttemptStore()
+
+	for i := 0; i < 3; i++ {
+		store.RecordLoginFailure("account:window@example.com", time.Hour)
+	}
+	if attempt, _ := store.GetLoginAttempt("account:window@example.com"); attempt.Failures != 3 {
+		t.Errorf("Failures = %d, want 3", attempt.Failures)
+	}
+
+	// Failures older than the window are forgotten
+	time.Sleep(2 * time.Millisecond)
+	attempt := store.RecordLoginFailure("account:window@example.com", time.Millisecond)
+	if attempt.Failures != 1 {
+		t.Errorf("Failures after the window = %d, want 1", attempt.Failures)
+	}
+}
+
+func TestLoginAttemptSweep(t *testing.T) {
+	store := newMemoryLoginAttemptStore()
+	now := time.Now()
+
+	store.RecordLoginFailure("account:stale@example.com", time.Millisecond)
+	store.RecordLoginFailure("account:recent@example.com", time.Hour)
+	store.RecordLoginFailure("account:locked@example.com", time.Millisecond)
+	store.LockLogin("account:locked@example.com", now.Add(time.Hour))
+
+	store.mu.Lock()
+	store.nextSweep = time.Time{}
+	store.sweepLocked(now.Add(time.Minute))
+	store.mu.Unlock()
+
+	tests := []struct {
+		key  string
+		kept bool
+	}{
+		{"account:stale@example.com", false},
+		{"account:recent@example.com", true},
+		{"account:locked@example.com", true},
+	}
+	for _, tt := range tests {
+		if _, exists := store.GetLoginAttempt(tt.key); exists != tt.kept {
+			t.Errorf("%s kept = %v, want %v", tt.key, exists, tt.kept)
+		}
+	}
+}
@@ -1,49 +1,83 @@
 package storage
 
 import (
+	"errors"
+	"hr-backend-system/fieldcrypt"
 	"hr-backend-system/models"
+	"log"
+	"strings"
 	"sync"
+	"time"
 )
 
+// Errors returned by user operations that protect the owner role
 var (
-	users       []models.User
+	ErrUserNotFound = errors.New("user not found")
+	ErrLastOwner    = errors.New("at least one owner must remain")
+	ErrNotOwner     = errors.New("user is not an owner")
+	ErrAnonymized   = errors.New("user is already anonymized")
+	ErrNotRecipient = errors.New("user cannot receive ownership")
+)
+
+var (
+	users       []userRecord
 	userCounter int = 1
 	mu          sync.RWMutex
 )
 
+// userRecord is a user as it is held in the store, with its personal data
+// encrypted, see encryptedFields
+type userRecord struct {
+	models.User
+	// Blind index of Email, lets GetUserByEmail find the user without
+	// decrypting every record
+	emailIndex string
+}
+
 // GetAllUsers returns all users
 func GetAllUsers() []models.User {
 	mu.RLock()
 	defer mu.RUnlock()
-	return users
+	all := make([]models.User, len(users))
+	for i, record := range users {
+		all[i] = openUser(record)
+	}
+	return all
 }
 
 // AddUser adds a new user
 func AddUser(user models.User) {
 	mu.Lock()
 	defer mu.Unlock()
-	users = append(users, user)
+	users = append(users, sealUser(user))
 }
 
 // GetUserByID returns a user by ID
 func GetUserByID(id int) (models.User, bool) {
 	mu.RLock()
 	defer mu.RUnlock()
-	for _, user := range users {
-		if user.ID == id {
-			return user, true
+	for _, record := range users {
+		if record.ID == id {
+			return openUser(record), true
 		}
 	}
 	return models.User{}, false
 }
 
-// GetUserByEmail returns a user by email
+// GetUserByEmail returns a user by email, ignoring case and surrounding
+// spaces
 func GetUserByEmail(email string) (models.User, bool) {
+	email = normalizeEmail(email)
+	index := fieldcrypt.BlindIndex(email)
 	mu.RLock()
 	defer mu.RUnlock()
-	for _, user := range users {
-		if user.Email == email {
-			return user, true
+	for _, record := range users {
+		if record.emailIndex == index {
+			user := openUser(record)
+			// Guards against index collisions
+			if normalizeEmail(user.Email) == email {
+				return user, true
+			}
 		}
 	}
 	return models.User{}, false
@@ -53,26 +87,161 @@ func GetUserByEmail(email string) (models.User, bool) {
 func UpdateUser(id int, updatedUser models.User) bool {
 	mu.Lock()
 	defer mu.Unlock()
-	for i, user := range users {
-		if user.ID == id {
-			users[i] = updatedUser
+	for i, record := range users {
+		if record.ID == id {
+			users[i] = sealUser(updatedUser)
 			return true
 		}
 	}
 	return false
 }
 
-// DeleteUser deletes a user
-func DeleteUser(id int) (models.User, bool) {
+// DeleteUser deletes a user. The last owner cannot be deleted.
+func DeleteUser(id int) (models.User, error) {
 	mu.Lock()
 	defer mu.Unlock()
-	for i, user := range users {
-		if user.ID == id {
+	for i, record := range users {
+		if record.ID == id {
+			if record.IsOwner() && !record.IsAnonymized() && countOwnersLocked() == 1 {
+				return models.User{}, ErrLastOwner
+			}
 			users = append(users[:i], users[i+1:]...)
-			return user, true
+			return openUser(record), nil
 		}
 	}
-	return models.User{}, false
+	return models.User{}, ErrUserNotFound
+}
+
+// AnonymizeUser scrubs the personal data of a user in place, see
+// models.User.Anonymize. Like DeleteUser it refuses the last owner.
+func AnonymizeUser(id int) (before models.User, after models.User, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	for i, record := range users {
+		if record.ID == id {
+			if record.IsAnonymized() {
+				return models.User{}, models.User{}, ErrAnonymized
+			}
+			if record.IsOwner() && countOwnersLocked() == 1 {
+				return models.User{}, models.User{}, ErrLastOwner
+			}
+			before = openUser(record)
+			after = before
+			after.Anonymize(time.Now())
+			users[i] = sealUser(after)
+			return before, after, nil
+		}
+	}
+	return models.User{}, models.User{}, ErrUserNotFound
+}
+
+// TransferOwnership makes toID an owner and demotes fromID to admin in one
+// step, so the number of owners never drops. The recipient is checked
+// again under the lock, as its role may have changed since the offer.
+func TransferOwnership(fromID, toID int) (from models.User, to models.User, err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	fromIndex, toIndex := -1, -1
+	for i, record := range users {
+		switch record.ID {
+		case fromID:
+			fromIndex = i
+		case toID:
+			toIndex = i
+		}
+	}
+	if fromIndex == -1 || toIndex == -1 {
+		return models.User{}, models.User{}, ErrUserNotFound
+	}
+	if !users[fromIndex].IsOwner() {
+		return models.User{}, models.User{}, ErrNotOwner
+	}
+	if to := users[toIndex]; !to.IsStaff() || to.IsOwner() || to.IsAnonymized() {
+		return models.User{}, models.User{}, ErrNotRecipient
+	}
+
+	now := time.Now()
+	users[toIndex].Type = models.UserTypeOwner
+	users[toIndex].UpdatedAt = now
+	users[fromIndex].Type = models.UserTypeAdmin
+	users[fromIndex].UpdatedAt = now
+	return openUser(users[fromIndex]), openUser(users[toIndex]), nil
+}
+
+// ReencryptUsers seals the personal data of every user again with the
+// current key, after a key rotation. It returns the number of users that
+// had data sealed with an older key.
+func ReencryptUsers() int {
+	mu.Lock()
+	defer mu.Unlock()
+	currentKeyID := fieldcrypt.CurrentKeyID()
+	count := 0
+	for i, record := range users {
+		stale := false
+		for _, field := range encryptedFields(&record.User) {
+			if *field != "" && fieldcrypt.KeyID(*field) != currentKeyID {
+				stale = true
+			}
+		}
+		if stale {
+			users[i] = sealUser(openUser(record))
+			count++
+		}
+	}
+	return count
+}
+
+// countOwnersLocked counts the owners that can still log in
+func countOwnersLocked() int {
+	count := 0
+	for _, record := range users {
+		if record.IsOwner() && !record.IsAnonymized() {
+			count++
+		}
+	}
+	return count
+}
+
+// encryptedFields returns the personal data of user that is only stored
+// encrypted. Further fields such as addresses or ID numbers belong here.
+func encryptedFields(user *models.User) []*string {
+	return []*string{
+		&user.Name,
+		&user.Email,
+		&user.PendingEmail,
+		&user.PhoneNumber,
+		&user.TOTPSecret,
+		&user.TOTPPendingSecret,
+	}
+}
+
+// normalizeEmail returns the form email addresses are indexed in, so
+// lookups match however the address was typed
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func sealUser(user models.User) userRecord {
+	record := userRecord{User: user, emailIndex: fieldcrypt.BlindIndex(normalizeEmail(user.Email))}
+	for _, field := range encryptedFields(&record.User) {
+		*field = fieldcrypt.Encrypt(*field)
+	}
+	return record
+}
+
+// openUser decrypts a stored user. A field that cannot be decrypted, e.g.
+// because its master key was removed, is logged and left sealed.
+func openUser(record userRecord) models.User {
+	user := record.User
+	for _, field := range encryptedFields(&user) {
+		value, err := fieldcrypt.Decrypt(*field)
+		if err != nil {
+			log.Printf("ERROR: failed to decrypt data of user %d: %v", user.ID, err)
+			continue
+		}
+		*field = value
+	}
+	return user
 }
 
 // GetNextUserID returns the next available user ID
@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"hr-backend-system/fieldcrypt"
+	"hr-backend-system/models"
+	"testing"
+	"time"
+)
+
+func TestGetUserByEmail(t *testing.T) {
+	AddUser(models.User{ID: 9001, Name: "Lookup", Email: "Lookup@Example.com"})
+
+	tests := []struct {
+		email string
+		found bool
+	}{
+		{"Lookup@Example.com", true},
+		{"lookup@example.com", true},
+		{"LOOKUP@EXAMPLE.COM", true},
+		{"  lookup@example.com ", true},
+		{"lookup@example.org", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			user, found := GetUserByEmail(tt.email)
+			if found != tt.found {
+				t.Fatalf("found = %v, want %v", found, tt.found)
+			}
+			if found && (user.ID != 9001 || user.Email != "Lookup@Example.com") {
+				t.Errorf("user = %d %q, want 9001 with the address as stored", user.ID, user.Email)
+			}
+		})
+	}
+}
+
+func TestUsersStoredEncrypted(t *testing.T) {
+	AddUser(models.User{ID: 9002, Name: "Sealed", Email: "sealed@example.com", PhoneNumber: "+15550100"})
+
+	mu.RLock()
+	var record userRecord
+	for _, r := range users {
+		if r.ID == 9002 {
+			record = r
+		}
+	}
+	mu.RUnlock()
+
+	for _, value := range []string{record.Name, record.Email, record.PhoneNumber} {
+		if !fieldcrypt.IsEncrypted(value) {
+			t.Errorf("field stored as %q, want it encrypted", value)
+		}
+	}
+	if user, _ := GetUserByID(9002); user.Email != "sealed@example.com" || user.PhoneNumber != "+15550100" {
+		t.Errorf("GetUserByID() = %+v, want the decrypted fields", user)
+	}
+}
+
+func TestReencryptUsers(t *testing.T) {
+	AddUser(models.User{ID: 9003, Name: "Rotated", Email: "rotated@example.com"})
+	ReencryptUsers()
+
+	keyID, err := fieldcrypt.Rotate()
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if n := ReencryptUsers(); n == 0 {
+		t.Fatal("ReencryptUsers() = 0 after Rotate, want the stored users")
+	}
+	if n := ReencryptUsers(); n != 0 {
+		t.Errorf("second ReencryptUsers() = %d, want 0", n)
+	}
+
+	mu.RLock()
+	for _, record := range users {
+		if record.ID == 9003 && fieldcrypt.KeyID(record.Email) != keyID {
+			t.Errorf("email sealed with key %q, want %q", fieldcrypt.KeyID(record.Email), keyID)
+		}
+	}
+	mu.RUnlock()
+
+	if user, found := GetUserByEmail("rotated@example.com"); !found || user.Name != "Rotated" {
+		t.Errorf("GetUserByEmail() after rotation = %+v, %v", user, found)
+	}
+}
+
+func TestTransferOwnership(t *testing.T) {
+	anonymizedAt := time.Now()
+	tests := []struct {
+		name      string
+		from      models.User
+		to        models.User
+		wantErr   error
+		wantOwner bool
+	}{
+		{"admin recipient", models.User{Type: models.UserTypeOwner}, models.User{Type: models.UserTypeAdmin}, nil, true},
+		{"viewer recipient", models.User{Type: models.UserTypeOwner}, models.User{Type: models.UserTypeViewer}, nil, true},
+		{"offering admin", models.User{Type: models.UserTypeAdmin}, models.User{Type: models.UserTypeAdmin}, ErrNotOwner, false},
+		{"external recipient", models.User{Type: models.UserTypeOwner}, models.User{Type: models.UserTypeJobSeeker}, ErrNotRecipient, false},
+		{"owner recipient", models.User{Type: models.UserTypeOwner}, models.User{Type: models.UserTypeOwner}, ErrNotRecipient, true},
+		{"anonymized recipient", models.User{Type: models.UserTypeOwner}, models.User{Type: models.UserTypeAdmin, AnonymizedAt: &anonymizedAt}, ErrNotRecipient, false},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.from.ID, tt.to.ID = 9100+2*i, 9101+2*i
+			AddUser(tt.from)
+			AddUser(tt.to)
+
+			_, _, err := TransferOwnership(tt.from.ID, tt.to.ID)
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			from, _ := GetUserByID(tt.from.ID)
+			to, _ := GetUserByID(tt.to.ID)
+			if tt.wantErr != nil && (from.Type != tt.from.Type || to.Type != tt.to.Type) {
+				t.Errorf("types changed to %s and %s", from.Type, to.Type)
+			}
+			if to.IsOwner() != tt.wantOwner {
+				t.Errorf("recipient owner = %v, want %v", to.IsOwner(), tt.wantOwner)
+			}
+		})
+	}
+}
+
+func TestLastOwnerCannotBeErased(t *testing.T) {
+	mu.Lock()
+	saved := users
+	users = nil
+	mu.Unlock()
+	defer func() {
+		mu.Lock()
+		users = saved
+		mu.Unlock()
+	}()
+
+	anonymizedAt := time.Now()
+	AddUser(models.User{ID: 9200, Type: models.UserTypeOwner})
+	AddUser(models.User{ID: 9201, Type: models.UserTypeOwner, AnonymizedAt: &anonymizedAt})
+
+	if _, err := DeleteUser(9200); err != ErrLastOwner {
+		t.Errorf("DeleteUser() err = %v, want %v", err, ErrLastOwner)
+	}
+	if _, _, err := AnonymizeUser(9200); err != ErrLastOwner {
+		t.Errorf("AnonymizeUser() err = %v, want %v", err, ErrLastOwner)
+	}
+
+	AddUser(models.User{ID: 9202, Type: models.UserTypeOwner})
+	if _, _, err := AnonymizeUser(9200); err != nil {
+		t.Fatalf("AnonymizeUser() with a second owner: %v", err)
+	}
+	if _, err := DeleteUser(9202); err != ErrLastOwner {
+		t.Errorf("DeleteUser() of the remaining owner err = %v, want %v", err, ErrLastOwner)
+	}
+}
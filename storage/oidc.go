@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"hr-backend-system/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	oidcStates     = map[string]models.OIDCLoginState{} // keyed by state hash
+	oidcIdentities = map[string]models.OIDCIdentity{}   // keyed by provider and subject
+	oidcMu         sync.Mutex
+)
+
+// AddOIDCLoginState stores the state of a login redirect and drops expired ones
+func AddOIDCLoginState(state models.OIDCLoginState) {
+	oidcMu.Lock()
+	defer oidcMu.Unlock()
+	now := time.Now()
+	for hash, existing := range oidcStates {
+		if now.After(existing.ExpiresAt) {
+			delete(oidcStates, hash)
+		}
+	}
+	oidcStates[state.StateHash] = state
+}
+
+// ConsumeOIDCLoginState removes and returns an unexpired login state.
+// Only the first caller for a state succeeds.
+func ConsumeOIDCLoginState(stateHash string) (models.OIDCLoginState, bool) {
+	oidcMu.Lock()
+	defer oidcMu.Unlock()
+	state, exists := oidcStates[stateHash]
+	if !exists {
+		return models.OIDCLoginState{}, false
+	}
+	delete(oidcStates, stateHash)
+	if time.Now().After(state.ExpiresAt) {
+		return models.OIDCLoginState{}, false
+	}
+	return state, true
+}
+
+// AddOIDCIdentity links a provider account to a user
+func AddOIDCIdentity(identity models.OIDCIdentity) {
+	oidcMu.Lock()
+	defer oidcMu.Unlock()
+	oidcIdentities[identityKey(identity.Provider, identity.Subject)] = identity
+}
+
+// GetOIDCIdentity returns the link for a provider account
+func GetOIDCIdentity(provider, subject string) (models.OIDCIdentity, bool) {
+	oidcMu.Lock()
+	defer oidcMu.Unlock()
+	identity, exists := oidcIdentities[identityKey(provider, subject)]
+	return identity, exists
+}
+
+// GetUserOIDCIdentities returns the provider accounts linked to a user
+func GetUserOIDCIdentities(userID int) []models.OIDCIdentity {
+	oidcMu.Lock()
+	defer oidcMu.Unlock()
+	result := []models.OIDCIdentity{}
+	for _, identity := range oidcIdentities {
+		if identity.UserID == userID {
+			result = append(result, identity)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result
+}
+
+// DeleteUserOIDCIdentities unlinks every provider account of a user
+func DeleteUserOIDCIdentities(userID int) {
+	oidcMu.Lock()
+	defer oidcMu.Unlock()
+	for key, identity := range oidcIdentities {
+		if identity.UserID == userID {
+			delete(oidcIdentities, key)
+		}
+	}
+}
+
+// DeleteOIDCIdentity removes the link for a provider account
+func DeleteOIDCIdentity(provider, subject string) {
+	oidcMu.Lock()
+	defer oidcMu.Unlock()
+	delete(oidcIdentities, identityKey(provider, subject))
+}
+
+func identityKey(provider, subject string) string {
+	return provider + "|" + subject
+}
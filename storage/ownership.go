@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"hr-backend-system/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ownershipTransfers = map[string]models.OwnershipTransfer{}
+	ownershipMu        sync.Mutex
+)
+
+// AddOwnershipTransfer stores a new transfer and cancels any other pending
+// transfer from the same owner, so only the latest offer can be accepted
+func AddOwnershipTransfer(transfer models.OwnershipTransfer) {
+	ownershipMu.Lock()
+	defer ownershipMu.Unlock()
+	now := time.Now()
+	for id, existing := range ownershipTransfers {
+		if existing.FromUserID == transfer.FromUserID && existing.IsPending(now) {
+			existing.Status = models.OwnershipTransferCancelled
+			existing.CompletedAt = &now
+			ownershipTransfers[id] = existing
+		}
+	}
+	ownershipTransfers[transfer.ID] = transfer
+}
+
+// GetOwnershipTransfer returns a transfer by ID
+func GetOwnershipTransfer(id string) (models.OwnershipTransfer, bool) {
+	ownershipMu.Lock()
+	defer ownershipMu.Unlock()
+	transfer, exists := ownershipTransfers[id]
+	return expireTransfer(transfer), exists
+}
+
+// GetUserOwnershipTransfers returns the transfers a user sent or received,
+// newest first
+func GetUserOwnershipTransfers(userID int) []models.OwnershipTransfer {
+	ownershipMu.Lock()
+	defer ownershipMu.Unlock()
+	var result []models.OwnershipTransfer
+	for _, transfer := range ownershipTransfers {
+		if transfer.FromUserID == userID || transfer.ToUserID == userID {
+			result = append(result, expireTransfer(transfer))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	return result
+}
+
+// CloseOwnershipTransfer moves a pending transfer to its final status.
+// It returns false if the transfer was no longer pending, so only one of
+// concurrent accept, decline or cancel requests succeeds.
+func CloseOwnershipTransfer(id, status string) (models.OwnershipTransfer, bool) {
+	ownershipMu.Lock()
+	defer ownershipMu.Unlock()
+	transfer, exists := ownershipTransfers[id]
+	now := time.Now()
+	if !exists || !transfer.IsPending(now) {
+		return models.OwnershipTransfer{}, false
+	}
+	transfer.Status = status
+	transfer.CompletedAt = &now
+	ownershipTransfers[id] = transfer
+	return transfer, true
+}
+
+// UpdateOwnershipTransfer replaces a stored transfer
+func UpdateOwnershipTransfer(transfer models.OwnershipTransfer) {
+	ownershipMu.Lock()
+	defer ownershipMu.Unlock()
+	ownershipTransfers[transfer.ID] = transfer
+}
+
+// expireTransfer reports pending transfers past their deadline as expired
+func expireTransfer(transfer models.OwnershipTransfer) models.OwnershipTransfer {
+	if transfer.Status == models.OwnershipTransferPending && !time.Now().Before(transfer.ExpiresAt) {
+		transfer.Status = models.OwnershipTransferExpired
+	}
+	return transfer
+}
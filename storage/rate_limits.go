@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"hr-backend-system/models"
+	"math"
+	"sync"
+	"time"
+)
+
+// rateLimitSweepInterval is how often full buckets are dropped
+const rateLimitSweepInterval = time.Minute
+
+// memoryRateLimitStore is the in-memory RateLimitStore. Quotas are per
+// process, use a shared store when running several instances.
+type memoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]memoryBucket
+	nextSweep time.Time
+}
+
+// memoryBucket remembers when the bucket is full again, after which it
+// can be forgotten
+type memoryBucket struct {
+	models.TokenBucket
+	fullAt time.Time
+}
+
+func newMemoryRateLimitStore() *memoryRateLimitStore {
+	return &memoryRateLimitStore{buckets: make(map[string]memoryBucket)}
+}
+
+func (s *memoryRateLimitStore) TakeToken(key string, rate float64, burst int, now time.Time) (models.TokenBucket, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.After(s.nextSweep) {
+		for k, b := range s.buckets {
+			if now.After(b.fullAt) {
+				delete(s.buckets, k)
+			}
+		}
+		s.nextSweep = now.Add(rateLimitSweepInterval)
+	}
+
+	bucket := models.TokenBucket{Tokens: float64(burst), UpdatedAt: now}
+	if b, exists := s.buckets[key]; exists {
+		elapsed := now.Sub(b.UpdatedAt).Seconds()
+		bucket.Tokens = math.Min(float64(burst), b.Tokens+elapsed*rate)
+	}
+
+	taken := bucket.Tokens >= 1
+	if taken {
+		bucket.Tokens--
+	}
+
+	refill := time.Duration((float64(burst) - bucket.Tokens) / rate * float64(time.Second))
+	s.buckets[key] = memoryBucket{TokenBucket: bucket, fullAt: now.Add(refill)}
+	return bucket, taken
+}
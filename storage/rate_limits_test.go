@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTakeToken(t *testing.T) {
+	store := newMemoryRateLimitStore()
+	start := time.Now()
+
+	// One token per second with a burst of three
+	tests := []struct {
+		name       string
+		at         time.Duration
+		wantTaken  bool
+		wantTokens float64
+	}{
+		{"first request", 0, true, 2},
+		{"second request", 0, true, 1},
+		{"third request", 0, true, 0},
+		{"burst spent", 0, false, 0},
+		{"half refilled", 500 * time.Millisecond, false, 0.5},
+		{"one refilled", time.Second, true, 0},
+		{"refill capped at burst", time.Hour, true, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bucket, taken := store.TakeToken("ip:192.0.2.1", 1, 3, start.Add(tt.at))
+			if taken != tt.wantTaken {
+				t.Errorf("taken = %v, want %v", taken, tt.wantTaken)
+			}
+			if bucket.Tokens != tt.wantTokens {
+				t.Errorf("Tokens = %v, want %v", bucket.Tokens, tt.wantTokens)
+			}
+		})
+	}
+}
+
+func TestTakeTokenKeysAreIndependent(t *testing.T) {
+	store := newMemoryRateLimitStore()
+	now := time.Now()
+
+	if _, taken := store.TakeToken("ip:192.0.2.1", 1, 1, now); !taken {
+		t.Fatal("first request refused")
+	}
+	if _, taken := store.TakeToken("ip:192.0.2.1", 1, 1, now); taken {
+		t.Error("second request on the same key allowed")
+	}
+	if _, taken := store.TakeToken("ip:192.0.2.2", 1, 1, now); !taken {
+		t.Error("request on another key refused")
+	}
+}
+
+func TestRateLimitSweep(t *testing.T) {
+	store := newMemoryRateLimitStore()
+	now := time.Now()
+
+	store.TakeToken("ip:192.0.2.1", 1, 1, now)
+	store.TakeToken("ip:192.0.2.2", 0.001, 1, now)
+
+	// The first bucket is full again after a second, the second after 1000s
+	store.TakeToken("ip:192.0.2.3", 1, 1, now.Add(2*rateLimitSweepInterval))
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if _, exists := store.buckets["ip:192.0.2.1"]; exists {
+		t.Error("full bucket kept after the sweep")
+	}
+	if _, exists := store.buckets["ip:192.0.2.2"]; !exists {
+		t.Error("refilling bucket dropped by the sweep")
+	}
+}
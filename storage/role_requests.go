@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"hr-backend-system/models"
+	"sync"
+	"time"
+)
+
+var (
+	roleRequests       []models.RoleChangeRequest
+	roleRequestCounter int = 1
+	roleRequestMu      sync.RWMutex
+)
+
+// AddRoleChangeRequest stores a new request and returns it with its assigned ID
+func AddRoleChangeRequest(request models.RoleChangeRequest) models.RoleChangeRequest {
+	roleRequestMu.Lock()
+	defer roleRequestMu.Unlock()
+	request.ID = roleRequestCounter
+	roleRequestCounter++
+	roleRequests = append(roleRequests, request)
+	return request
+}
+
+// GetRoleChangeRequest returns a request by ID
+func GetRoleChangeRequest(id int) (models.RoleChangeRequest, bool) {
+	roleRequestMu.RLock()
+	defer roleRequestMu.RUnlock()
+	for _, request := range roleRequests {
+		if request.ID == id {
+			return request, true
+		}
+	}
+	return models.RoleChangeRequest{}, false
+}
+
+// GetRoleChangeRequests returns requests with the given status, newest
+// first. An empty status returns all requests.
+func GetRoleChangeRequests(status string) []models.RoleChangeRequest {
+	roleRequestMu.RLock()
+	defer roleRequestMu.RUnlock()
+	result := []models.RoleChangeRequest{}
+	for i := len(roleRequests) - 1; i >= 0; i-- {
+		if status == "" || roleRequests[i].Status == status {
+			result = append(result, roleRequests[i])
+		}
+	}
+	return result
+}
+
+// ReviewRoleChangeRequest records the decision on a pending request. It
+// returns false if the request does not exist or was already reviewed.
+func ReviewRoleChangeRequest(id int, status string, reviewerID int) (models.RoleChangeRequest, bool) {
+	roleRequestMu.Lock()
+	defer roleRequestMu.Unlock()
+	for i, request := range roleRequests {
+		if request.ID != id {
+			continue
+		}
+		if request.Status != models.RoleRequestPending {
+			return models.RoleChangeRequest{}, false
+		}
+		now := time.Now()
+		roleRequests[i].Status = status
+		roleRequests[i].ReviewedBy = reviewerID
+		roleRequests[i].ReviewedAt = &now
+		return roleRequests[i], true
+	}
+	return models.RoleChangeRequest{}, false
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"hr-backend-system/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	roles     map[string]models.Role
+	rolesOnce sync.Once
+	rolesMu   sync.RWMutex
+)
+
+// initRoles seeds the roles from the default mappings on first use
+func initRoles() {
+	rolesOnce.Do(func() {
+		now := time.Now()
+		roles = map[string]models.Role{}
+		for name, permissions := range models.DefaultRolePermissions {
+			roles[name] = models.Role{
+				Name:        name,
+				Permissions: append([]string(nil), permissions...),
+				Editable:    true,
+				UpdatedAt:   now,
+			}
+		}
+		roles[models.UserTypeOwner] = models.Role{
+			Name:        models.UserTypeOwner,
+			Permissions: allPermissions(),
+			UpdatedAt:   now,
+		}
+	})
+}
+
+// GetRoles returns all roles ordered by name
+func GetRoles() []models.Role {
+	initRoles()
+	rolesMu.RLock()
+	defer rolesMu.RUnlock()
+	result := make([]models.Role, 0, len(roles))
+	for _, role := range roles {
+		result = append(result, role)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// GetRole returns a role by name
+func GetRole(name string) (models.Role, bool) {
+	initRoles()
+	rolesMu.RLock()
+	defer rolesMu.RUnlock()
+	role, exists := roles[name]
+	return role, exists
+}
+
+// SetRolePermissions replaces the permissions of an editable role
+func SetRolePermissions(name string, permissions []string) (models.Role, bool) {
+	initRoles()
+	rolesMu.Lock()
+	defer rolesMu.Unlock()
+	role, exists := roles[name]
+	if !exists || !role.Editable {
+		return models.Role{}, false
+	}
+	role.Permissions = permissions
+	role.UpdatedAt = time.Now()
+	roles[name] = role
+	return role, true
+}
+
+// RoleHasPermission reports whether the role of a user type grants permission
+func RoleHasPermission(name, permission string) bool {
+	role, exists := GetRole(name)
+	return exists && role.HasPermission(permission)
+}
+
+func allPermissions() []string {
+	result := make([]string, len(models.PermissionRegistry))
+	for i, p := range models.PermissionRegistry {
+		result[i] = p.Name
+	}
+	return result
+}
@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"errors"
+	"hr-backend-system/models"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Refresh token errors returned by UseRefreshToken
+var (
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrRefreshTokenReused   = errors.New("refresh token already used")
+)
+
+// sessionSweepInterval is how often ended sessions and expired refresh
+// tokens are dropped
+const sessionSweepInterval = time.Minute
+
+var (
+	sessions         = map[string]models.Session{}
+	refreshTokens    = map[string]models.RefreshToken{} // keyed by token hash
+	sessionMu        sync.RWMutex
+	nextSessionSweep time.Time
+)
+
+// AddSession stores a new session
+func AddSession(session models.Session) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	sweepSessionsLocked(time.Now())
+	sessions[session.ID] = session
+}
+
+// GetSession returns a session by ID
+func GetSession(id string) (models.Session, bool) {
+	sessionMu.RLock()
+	defer sessionMu.RUnlock()
+	session, exists := sessions[id]
+	return session, exists
+}
+
+// IsSessionActive reports whether the session exists and has not been revoked or expired
+func IsSessionActive(id string) bool {
+	session, exists := GetSession(id)
+	return exists && session.IsActive()
+}
+
+// GetUserSessions returns the active sessions of a user, most recently used first
+func GetUserSessions(userID int) []models.Session {
+	sessionMu.RLock()
+	defer sessionMu.RUnlock()
+	result := []models.Session{}
+	for _, session := range sessions {
+		if session.UserID == userID && session.IsActive() {
+			result = append(result, session)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LastSeenAt.After(result[j].LastSeenAt)
+	})
+	return result
+}
+
+// GetAllUserSessions returns every session of a user including expired and
+// revoked ones, newest first
+func GetAllUserSessions(userID int) []models.Session {
+	sessionMu.RLock()
+	defer sessionMu.RUnlock()
+	result := []models.Session{}
+	for _, session := range sessions {
+		if session.UserID == userID {
+			result = append(result, session)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	return result
+}
+
+// TouchSession records activity on a session from the given IP address
+func TouchSession(id, ip string) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	if session, exists := sessions[id]; exists {
+		session.LastSeenAt = time.Now()
+		session.IP = ip
+		sessions[id] = session
+	}
+}
+
+// AddRefreshToken stores a refresh token and extends its session to the token's expiry
+func AddRefreshToken(token models.RefreshToken) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	sweepSessionsLocked(time.Now())
+	refreshTokens[token.TokenHash] = token
+	if session, exists := sessions[token.SessionID]; exists {
+		session.LastSeenAt = token.CreatedAt
+		session.ExpiresAt = token.ExpiresAt
+		sessions[token.SessionID] = session
+	}
+}
+
+// UseRefreshToken marks a refresh token as used and returns it. A token can
+// only be used once; presenting it again returns ErrRefreshTokenReused along
+// with the token so the caller can revoke its session.
+func UseRefreshToken(tokenHash string) (models.RefreshToken, error) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	token, exists := refreshTokens[tokenHash]
+	if !exists {
+		return models.RefreshToken{}, ErrRefreshTokenNotFound
+	}
+	if token.UsedAt != nil {
+		return token, ErrRefreshTokenReused
+	}
+	now := time.Now()
+	token.UsedAt = &now
+	refreshTokens[tokenHash] = token
+	return token, nil
+}
+
+// RevokeSession revokes a session and every refresh token issued in it
+func RevokeSession(id string) bool {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	return revokeSessionLocked(id, time.Now())
+}
+
+// RevokeUserSessions revokes all sessions of a user and returns how many were revoked
+func RevokeUserSessions(userID int) int {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	now := time.Now()
+	count := 0
+	for id, session := range sessions {
+		if session.UserID == userID && revokeSessionLocked(id, now) {
+			count++
+		}
+	}
+	return count
+}
+
+// DeleteUserSessions removes every session of a user and their refresh
+// tokens, e.g. when the user's personal data is erased
+func DeleteUserSessions(userID int) {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	for id, session := range sessions {
+		if session.UserID == userID {
+			delete(sessions, id)
+		}
+	}
+	for hash, token := range refreshTokens {
+		if token.UserID == userID {
+			delete(refreshTokens, hash)
+		}
+	}
+}
+
+// RevokeOtherUserSessions revokes all sessions of a user except keepID
+// and returns how many were revoked
+func RevokeOtherUserSessions(userID int, keepID string) int {
+	sessionMu.Lock()
+	defer sessionMu.Unlock()
+	now := time.Now()
+	count := 0
+	for id, session := range sessions {
+		if session.UserID == userID && id != keepID && revokeSessionLocked(id, now) {
+			count++
+		}
+	}
+	return count
+}
+
+// revokeSessionLocked revokes a session, sessionMu must be held
+func revokeSessionLocked(id string, now time.Time) bool {
+	session, exists := sessions[id]
+	if !exists || session.RevokedAt != nil {
+		return false
+	}
+	session.RevokedAt = &now
+	sessions[id] = session
+
+	// Drop the session's refresh tokens, they can never be used again
+	for hash, token := range refreshTokens {
+		if token.SessionID == id {
+			delete(refreshTokens, hash)
+		}
+	}
+	return true
+}
+
+// sweepSessionsLocked drops sessions that expired or were revoked and
+// refresh tokens past their expiry, used ones included. A token presented
+// after that is rejected as unknown instead of as reused, which no longer
+// matters as it could not be refreshed anyway. sessionMu must be held.
+func sweepSessionsLocked(now time.Time) {
+	if now.Before(nextSessionSweep) {
+		return
+	}
+	for id, session := range sessions {
+		if session.RevokedAt != nil || now.After(session.ExpiresAt) {
+			delete(sessions, id)
+		}
+	}
+	for hash, token := range refreshTokens {
+		if _, exists := sessions[token.SessionID]; !exists || now.After(token.ExpiresAt) {
+			delete(refreshTokens, hash)
+		}
+	}
+	nextSessionSweep = now.Add(sessionSweepInterval)
+}
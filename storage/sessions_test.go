@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"errors"
+	"hr-backend-system/models"
+	"testing"
+	"time"
+)
+
+func addTestSession(t *testing.T, id string, userID int, expiresAt time.Time, tokenHashes ...string) {
+	t.Helper()
+	now := time.Now()
+	AddSession(models.Session{ID: id, UserID: userID, CreatedAt: now, LastSeenAt: now, ExpiresAt: expiresAt})
+	for _, hash := range tokenHashes {
+		AddRefreshToken(models.RefreshToken{TokenHash: hash, SessionID: id, UserID: userID, ExpiresAt: expiresAt, CreatedAt: now})
+	}
+}
+
+func TestUseRefreshToken(t *testing.T) {
+	addTestSession(t, "reuse-session", 101, time.Now().Add(time.Hour), "reuse-token")
+
+	tests := []struct {
+		name    string
+		hash    string
+		wantErr error
+	}{
+		{"first use", "reuse-token", nil},
+		{"reuse", "reuse-token", ErrRefreshTokenReused},
+		{"unknown", "no-such-token", ErrRefreshTokenNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := UseRefreshToken(tt.hash)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("UseRefreshToken() error = %v, want %v", err, tt.wantErr)
+			}
+			// A reused token is returned so its session can be revoked
+			if tt.wantErr == ErrRefreshTokenReused && token.SessionID != "reuse-session" {
+				t.Errorf("SessionID = %q, want reuse-session", token.SessionID)
+			}
+		})
+	}
+}
+
+func TestRevokeSessionDropsRefreshTokens(t *testing.T) {
+	addTestSession(t, "revoke-session", 102, time.Now().Add(time.Hour), "revoke-token-1", "revoke-token-2")
+
+	if !RevokeSession("revoke-session") {
+		t.Fatal("RevokeSession() = false, want true")
+	}
+	if IsSessionActive("revoke-session") {
+		t.Error("session is still active after revocation")
+	}
+	for _, hash := range []string{"revoke-token-1", "revoke-token-2"} {
+		if _, err := UseRefreshToken(hash); !errors.Is(err, ErrRefreshTokenNotFound) {
+			t.Errorf("UseRefreshToken(%s) error = %v, want %v", hash, err, ErrRefreshTokenNotFound)
+		}
+	}
+	if RevokeSession("revoke-session") {
+		t.Error("revoking twice returned true")
+	}
+}
+
+func TestRevokeOtherUserSessions(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	addTestSession(t, "other-keep", 103, expiresAt)
+	addTestSession(t, "other-drop-1", 103, expiresAt)
+	addTestSession(t, "other-drop-2", 103, expiresAt)
+	addTestSession(t, "other-foreign", 104, expiresAt)
+
+	if revoked := RevokeOtherUserSessions(103, "other-keep"); revoked != 2 {
+		t.Errorf("RevokeOtherUserSessions() = %d, want 2", revoked)
+	}
+	for id, active := range map[string]bool{"other-keep": true, "other-drop-1": false, "other-drop-2": false, "other-foreign": true} {
+		if IsSessionActive(id) != active {
+			t.Errorf("IsSessionActive(%s) = %v, want %v", id, !active, active)
+		}
+	}
+}
+
+func TestSweepSessions(t *testing.T) {
+	now := time.Now()
+	addTestSession(t, "sweep-live", 105, now.Add(time.Hour), "sweep-live-token")
+	addTestSession(t, "sweep-expired", 105, now.Add(time.Hour), "sweep-expired-token")
+	addTestSession(t, "sweep-revoked", 105, now.Add(time.Hour), "sweep-revoked-token")
+	RevokeSession("sweep-revoked")
+
+	sessionMu.Lock()
+	session := sessions["sweep-expired"]
+	session.ExpiresAt = now.Add(-time.Second)
+	sessions["sweep-expired"] = session
+	nextSessionSweep = time.Time{}
+	sweepSessionsLocked(now)
+	sessionMu.Unlock()
+
+	for id, kept := range map[string]bool{"sweep-live": true, "sweep-expired": false, "sweep-revoked": false} {
+		if _, exists := GetSession(id); exists != kept {
+			t.Errorf("session %s kept = %v, want %v", id, exists, kept)
+		}
+	}
+	sessionMu.RLock()
+	_, liveToken := refreshTokens["sweep-live-token"]
+	_, expiredToken := refreshTokens["sweep-expired-token"]
+	sessionMu.RUnlock()
+	if !liveToken || expiredToken {
+		t.Errorf("refresh tokens kept: live %v, expired %v, want true and false", liveToken, expiredToken)
+	}
+}
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"hr-backend-system/config"
+	"hr-backend-system/models"
+	"sync"
+)
+
+var (
+	settings     models.Settings
+	settingsOnce sync.Once
+	settingsMu   sync.RWMutex
+)
+
+// initSettings seeds the settings from config on first use
+func initSettings() {
+	settingsOnce.Do(func() {
+		cfg := config.Get()
+		settings = models.Settings{
+			RequireEmailVerification: cfg.RequireEmailVerification,
+			RequireAdminTwoFactor:    cfg.RequireAdminTwoFactor,
+			UserDeletionMode:         cfg.UserDeletionMode,
+		}
+	})
+}
+
+// GetSettings returns the current settings
+func GetSettings() models.Settings {
+	initSettings()
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+	return settings
+}
+
+// UpdateSettings replaces the current settings
+func UpdateSettings(updated models.Settings) {
+	initSettings()
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	settings = updated
+}
@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// memorySubjectKeyStore is the in-memory SubjectKeyStore. Erased users keep
+// an empty key so no new key is created for them.
+type memorySubjectKeyStore struct {
+	mu   sync.RWMutex
+	keys map[int]string
+}
+
+func newMemorySubjectKeyStore() *memorySubjectKeyStore {
+	return &memorySubjectKeyStore{keys: make(map[int]string)}
+}
+
+func (s *memorySubjectKeyStore) GetSubjectKey(userID int) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, exists := s.keys[userID]
+	return key, exists && key == ""
+}
+
+func (s *memorySubjectKeyStore) AddSubjectKey(userID int, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, exists := s.keys[userID]; exists {
+		return existing, nil
+	}
+	s.keys[userID] = key
+	return key, nil
+}
+
+func (s *memorySubjectKeyStore) ShredSubjectKey(userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[userID] = ""
+	return nil
+}
+
+// fileSubjectKeyStore keeps the keys in a JSON file that is rewritten on
+// every change, so shredded keys do not linger in it
+type fileSubjectKeyStore struct {
+	memorySubjectKeyStore
+	path string
+}
+
+// NewFileSubjectKeyStore loads the subject keys stored at path. The file is
+// created on the first change.
+func NewFileSubjectKeyStore(path string) (SubjectKeyStore, error) {
+	store := &fileSubjectKeyStore{memorySubjectKeyStore: *newMemorySubjectKeyStore(), path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read subject keys: %w", err)
+	}
+
+	// JSON object keys are strings, so IDs are stored as such
+	var keys map[string]string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("invalid subject key file %s: %w", path, err)
+	}
+	for id, key := range keys {
+		userID, err := strconv.Atoi(id)
+		if err != nil {
+			return nil, fmt.Errorf("invalid subject key file %s: user ID %q", path, id)
+		}
+		store.keys[userID] = key
+	}
+	return store, nil
+}
+
+func (s *fileSubjectKeyStore) AddSubjectKey(userID int, key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, exists := s.keys[userID]; exists {
+		return existing, nil
+	}
+	s.keys[userID] = key
+	if err := s.saveLocked(); err != nil {
+		delete(s.keys, userID)
+		return "", err
+	}
+	return key, nil
+}
+
+func (s *fileSubjectKeyStore) ShredSubjectKey(userID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, existed := s.keys[userID]
+	s.keys[userID] = ""
+	if err := s.saveLocked(); err != nil {
+		if existed {
+			s.keys[userID] = previous
+		} else {
+			delete(s.keys, userID)
+		}
+		return err
+	}
+	return nil
+}
+
+// saveLocked replaces the key file in one step so a crash cannot leave it
+// half written
+func (s *fileSubjectKeyStore) saveLocked() error {
+	keys := make(map[string]string, len(s.keys))
+	for id, key := range s.keys {
+		keys[strconv.Itoa(id)] = key
+	}
+	data, err := json.Marshal(keys)
+	if err != nil {
+		return fmt.Errorf("encode subject keys: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("write subject keys: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write subject keys: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write subject keys: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write subject keys: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("write subject keys: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileSubjectKeyStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit_keys.json")
+	store, err := NewFileSubjectKeyStore(path)
+	if err != nil {
+		t.Fatalf("NewFileSubjectKeyStore: %v", err)
+	}
+
+	if key, err := store.AddSubjectKey(1, "key-one"); err != nil || key != "key-one" {
+		t.Fatalf("AddSubjectKey() = %q, %v", key, err)
+	}
+	if key, _ := store.AddSubjectKey(1, "other"); key != "key-one" {
+		t.Errorf("second AddSubjectKey() = %q, want the first key", key)
+	}
+	store.AddSubjectKey(2, "key-two")
+	if err := store.ShredSubjectKey(2); err != nil {
+		t.Fatalf("ShredSubjectKey: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "key-two") {
+		t.Error("shredded key still in the file")
+	}
+
+	reloaded, err := NewFileSubjectKeyStore(path)
+	if err != nil {
+		t.Fatalf("NewFileSubjectKeyStore of existing file: %v", err)
+	}
+	tests := []struct {
+		userID     int
+		wantKey    string
+		wantErased bool
+	}{
+		{1, "key-one", false},
+		{2, "", true},
+		{3, "", false},
+	}
+	for _, tt := range tests {
+		key, erased := reloaded.GetSubjectKey(tt.userID)
+		if key != tt.wantKey || erased != tt.wantErased {
+			t.Errorf("GetSubjectKey(%d) = %q, %v, want %q, %v", tt.userID, key, erased, tt.wantKey, tt.wantErased)
+		}
+	}
+	// Erased users do not get a new key
+	if key, _ := reloaded.AddSubjectKey(2, "new"); key != "" {
+		t.Errorf("AddSubjectKey() for an erased user = %q, want empty", key)
+	}
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"hr-backend-system/models"
+	"sync"
+	"time"
+)
+
+var (
+	oneTimeTokens = map[string]models.OneTimeToken{} // keyed by token hash
+	tokenMu       sync.Mutex
+)
+
+// AddOneTimeToken stores a token, replacing any earlier token the user
+// holds for the same purpose so only the latest one works
+func AddOneTimeToken(token models.OneTimeToken) {
+	tokenMu.Lock()
+	defer tokenMu.Unlock()
+	for hash, existing := range oneTimeTokens {
+		if existing.UserID == token.UserID && existing.Purpose == token.Purpose {
+			delete(oneTimeTokens, hash)
+		}
+	}
+	oneTimeTokens[token.TokenHash] = token
+}
+
+// GetOneTimeToken returns an unexpired token for the given purpose without consuming it
+func GetOneTimeToken(tokenHash, purpose string) (models.OneTimeToken, bool) {
+	tokenMu.Lock()
+	defer tokenMu.Unlock()
+	token, exists := oneTimeTokens[tokenHash]
+	if !exists || token.Purpose != purpose || time.Now().After(token.ExpiresAt) {
+		return models.OneTimeToken{}, false
+	}
+	return token, true
+}
+
+// ConsumeOneTimeToken removes and returns an unexpired token for the given purpose.
+// Only the first caller for a token succeeds.
+func ConsumeOneTimeToken(tokenHash, purpose string) (models.OneTimeToken, bool) {
+	tokenMu.Lock()
+	defer tokenMu.Unlock()
+	token, exists := oneTimeTokens[tokenHash]
+	if !exists || token.Purpose != purpose {
+		return models.OneTimeToken{}, false
+	}
+	delete(oneTimeTokens, tokenHash)
+	if time.Now().After(token.ExpiresAt) {
+		return models.OneTimeToken{}, false
+	}
+	return token, true
+}
+
+// RecordOneTimeTokenFailure counts a failed attempt against a token and
+// deletes it once maxAttempts is reached
+func RecordOneTimeTokenFailure(tokenHash string, maxAttempts int) {
+	tokenMu.Lock()
+	defer tokenMu.Unlock()
+	token, exists := oneTimeTokens[tokenHash]
+	if !exists {
+		return
+	}
+	token.Attempts++
+	if token.Attempts >= maxAttempts {
+		delete(oneTimeTokens, tokenHash)
+		return
+	}
+	oneTimeTokens[tokenHash] = token
+}
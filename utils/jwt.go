@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"hr-backend-system/config"
+	"hr-backend-system/models"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Claims represents the payload of an access token
+type Claims struct {
+	UserID         int    `json:"uid"`
+	Type           string `json:"type"`
+	SessionID      string `json:"sid"`
+	ImpersonatorID int    `json:"imp,omitempty"` // Admin acting as UserID, set on impersonation tokens
+	jwt.RegisteredClaims
+}
+
+// GenerateAccessToken creates a signed access token for the given user and session
+func GenerateAccessToken(user models.User, sessionID string) (string, time.Time, error) {
+	return signAccessToken(user, sessionID, 0, config.Get().AccessTokenTTL)
+}
+
+// GenerateImpersonationToken creates a signed access token that lets the
+// impersonator act as user for ttl
+func GenerateImpersonationToken(user models.User, impersonatorID int, sessionID string, ttl time.Duration) (string, time.Time, error) {
+	return signAccessToken(user, sessionID, impersonatorID, ttl)
+}
+
+func signAccessToken(user models.User, sessionID string, impersonatorID int, ttl time.Duration) (string, time.Time, error) {
+	cfg := config.Get()
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	claims := Claims{
+		UserID:         user.ID,
+		Type:           user.Type,
+		SessionID:      sessionID,
+		ImpersonatorID: impersonatorID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    cfg.JWTIssuer,
+			Subject:   strconv.Itoa(user.ID),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString([]byte(cfg.JWTSecret))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("sign access token: %w", err)
+	}
+	return signed, expiresAt, nil
+}
+
+// ParseAccessToken validates a signed access token and returns its claims
+func ParseAccessToken(tokenString string) (*Claims, error) {
+	cfg := config.Get()
+
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		return []byte(cfg.JWTSecret), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(cfg.JWTIssuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.UserID < 1 {
+		return nil, errors.New("token is missing user ID")
+	}
+	return claims, nil
+}
@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"hr-backend-system/config"
+	"hr-backend-system/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestAccessTokenRoundTrip(t *testing.T) {
+	user := models.User{ID: 7, Type: models.UserTypeOperator}
+	token, expiresAt, err := GenerateAccessToken(user, "session-1")
+	if err != nil {
+		t.Fatalf("GenerateAccessToken: %v", err)
+	}
+	if !expiresAt.After(time.Now()) {
+		t.Errorf("expiresAt = %v, want a time in the future", expiresAt)
+	}
+
+	claims, err := ParseAccessToken(token)
+	if err != nil {
+		t.Fatalf("ParseAccessToken: %v", err)
+	}
+	if claims.UserID != 7 || claims.Type != models.UserTypeOperator || claims.SessionID != "session-1" {
+		t.Errorf("claims = %+v, want user 7, operator, session-1", claims)
+	}
+	if claims.ImpersonatorID != 0 {
+		t.Errorf("ImpersonatorID = %d, want 0", claims.ImpersonatorID)
+	}
+}
+
+func TestParseAccessTokenRejects(t *testing.T) {
+	cfg := config.Get()
+	sign := func(method jwt.SigningMethod, key any, claims Claims) string {
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		return token
+	}
+	valid := func() Claims {
+		return Claims{
+			UserID: 1,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    cfg.JWTIssuer,
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+		}
+	}
+	good, _, err := GenerateAccessToken(models.User{ID: 1}, "s")
+	if err != nil {
+		t.Fatalf("GenerateAccessToken: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"wrong secret", sign(jwt.SigningMethodHS256, []byte("other"), valid())},
+		{"none algorithm", sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, valid())},
+		{"expired", func() string {
+			claims := valid()
+			claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+			return sign(jwt.SigningMethodHS256, []byte(cfg.JWTSecret), claims)
+		}()},
+		{"no expiry", func() string {
+			claims := valid()
+			claims.ExpiresAt = nil
+			return sign(jwt.SigningMethodHS256, []byte(cfg.JWTSecret), claims)
+		}()},
+		{"other issuer", func() string {
+			claims := valid()
+			claims.Issuer = "someone-else"
+			return sign(jwt.SigningMethodHS256, []byte(cfg.JWTSecret), claims)
+		}()},
+		{"no user", func() string {
+			claims := valid()
+			claims.UserID = 0
+			return sign(jwt.SigningMethodHS256, []byte(cfg.JWTSecret), claims)
+		}()},
+		{"tampered", good[:strings.LastIndex(good, ".")] + ".invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseAccessToken(tt.token); err == nil {
+				t.Error("ParseAccessToken succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestImpersonationTokenCarriesImpersonator(t *testing.T) {
+	token, _, err := GenerateImpersonationToken(models.User{ID: 12}, 3, "s", time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateImpersonationToken: %v", err)
+	}
+	claims, err := ParseAccessToken(token)
+	if err != nil {
+		t.Fatalf("ParseAccessToken: %v", err)
+	}
+	if claims.UserID != 12 || claims.ImpersonatorID != 3 {
+		t.Errorf("claims = user %d impersonator %d, want 12 and 3", claims.UserID, claims.ImpersonatorID)
+	}
+}
@@ -0,0 +1,211 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hr-backend-system/config"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hashing algorithms
+const (
+	HashAlgorithmArgon2id = "argon2id"
+	HashAlgorithmBcrypt   = "bcrypt"
+)
+
+var errInvalidHash = errors.New("invalid password hash")
+
+// PasswordHasher creates and verifies encoded password hashes of one algorithm
+type PasswordHasher interface {
+	// Hash returns the encoded hash of password, including salt and parameters
+	Hash(password string) (string, error)
+	// Verify reports whether password matches the encoded hash
+	Verify(encoded, password string) bool
+	// NeedsRehash reports whether the encoded hash was made with other parameters
+	NeedsRehash(encoded string) bool
+}
+
+// Argon2idHasher hashes passwords with argon2id. Hashes use the PHC string
+// format, e.g. $argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>.
+type Argon2idHasher struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  int
+	KeyLength   uint32
+}
+
+// Hash implements PasswordHasher
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify implements PasswordHasher
+func (h Argon2idHasher) Verify(encoded, password string) bool {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return false
+	}
+	candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+	return subtle.ConstantTimeCompare(key, candidate) == 1
+}
+
+// NeedsRehash implements PasswordHasher
+func (h Argon2idHasher) NeedsRehash(encoded string) bool {
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return params.Memory != h.Memory || params.Iterations != h.Iterations ||
+		params.Parallelism != h.Parallelism || len(salt) != h.SaltLength || uint32(len(key)) != h.KeyLength
+}
+
+func decodeArgon2id(encoded string) (params Argon2idHasher, salt, key []byte, err error) {
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != HashAlgorithmArgon2id {
+		return params, nil, nil, errInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errInvalidHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, errInvalidHash
+	}
+	// argon2.IDKey panics on zero time or parallelism
+	if params.Iterations == 0 || params.Parallelism == 0 {
+		return params, nil, nil, errInvalidHash
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return params, nil, nil, errInvalidHash
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(key) == 0 {
+		return params, nil, nil, errInvalidHash
+	}
+	return params, salt, key, nil
+}
+
+// BcryptHasher hashes passwords with bcrypt
+type BcryptHasher struct {
+	Cost int
+}
+
+// Hash implements PasswordHasher
+func (h BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Verify implements PasswordHasher
+func (h BcryptHasher) Verify(encoded, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)) == nil
+}
+
+// NeedsRehash implements PasswordHasher
+func (h BcryptHasher) NeedsRehash(encoded string) bool {
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != h.Cost
+}
+
+// DefaultHasher returns the hasher for new passwords, configured by
+// PASSWORD_HASH_ALGORITHM and its tuning parameters
+func DefaultHasher() PasswordHasher {
+	cfg := config.Get()
+	if cfg.PasswordHashAlgorithm == HashAlgorithmBcrypt {
+		return BcryptHasher{Cost: cfg.BcryptCost}
+	}
+	return Argon2idHasher{
+		Memory:      uint32(cfg.Argon2Memory),
+		Iterations:  uint32(cfg.Argon2Iterations),
+		Parallelism: uint8(cfg.Argon2Parallelism),
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// hashAlgorithm detects the algorithm of an encoded hash, "" if unknown
+func hashAlgorithm(encoded string) string {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return HashAlgorithmArgon2id
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		return HashAlgorithmBcrypt
+	}
+	return ""
+}
+
+// HashPassword returns the encoded hash of password using the default hasher
+func HashPassword(password string) (string, error) {
+	return DefaultHasher().Hash(password)
+}
+
+// CheckPassword reports whether password matches the stored hash, whatever
+// algorithm it was made with. The parameters are read from the hash itself.
+func CheckPassword(hash, password string) bool {
+	switch hashAlgorithm(hash) {
+	case HashAlgorithmArgon2id:
+		return Argon2idHasher{}.Verify(hash, password)
+	case HashAlgorithmBcrypt:
+		return BcryptHasher{}.Verify(hash, password)
+	}
+	return false
+}
+
+var (
+	dummyHash     string
+	dummyHashOnce sync.Once
+)
+
+// DummyPasswordHash returns a hash of a random password made with the
+// default hasher. Checking a password against it when an account does not
+// exist takes as long as checking a real one, so response times do not
+// reveal which accounts exist. No password matches it.
+func DummyPasswordHash() string {
+	dummyHashOnce.Do(func() {
+		password := make([]byte, 32)
+		if _, err := rand.Read(password); err != nil {
+			return
+		}
+		dummyHash, _ = HashPassword(base64.RawStdEncoding.EncodeToString(password))
+	})
+	return dummyHash
+}
+
+// PasswordNeedsRehash reports whether a stored hash should be replaced
+// because it uses another algorithm or other parameters than the default
+func PasswordNeedsRehash(hash string) bool {
+	if hashAlgorithm(hash) != config.Get().PasswordHashAlgorithm {
+		return true
+	}
+	return DefaultHasher().NeedsRehash(hash)
+}
+
+// PasswordInHistory reports whether password matches any of the given hashes
+func PasswordInHistory(history []string, password string) bool {
+	for _, hash := range history {
+		if CheckPassword(hash, password) {
+			return true
+		}
+	}
+	return false
+}
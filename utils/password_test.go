@@ -0,0 +1,135 @@
+package utils
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Cheap parameters keep the tests fast
+var testArgon2 = Argon2idHasher{Memory: 64, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestCheckPassword(t *testing.T) {
+	argonHash, err := testArgon2.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("Argon2idHasher.Hash: %v", err)
+	}
+	bcryptHash, err := BcryptHasher{Cost: bcrypt.MinCost}.Hash("correct horse")
+	if err != nil {
+		t.Fatalf("BcryptHasher.Hash: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		hash     string
+		password string
+		want     bool
+	}{
+		{"argon2id match", argonHash, "correct horse", true},
+		{"argon2id mismatch", argonHash, "wrong horse", false},
+		{"bcrypt match", bcryptHash, "correct horse", true},
+		{"bcrypt mismatch", bcryptHash, "wrong horse", false},
+		{"empty hash", "", "", false},
+		{"unknown algorithm", "$md5$abc", "correct horse", false},
+		{"truncated argon2id", "$argon2id$v=19$m=64,t=1,p=1$c2FsdA", "correct horse", false},
+		{"zero iterations", "$argon2id$v=19$m=64,t=0,p=1$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U", "x", false},
+		{"zero parallelism", "$argon2id$v=19$m=64,t=1,p=0$c2FsdHNhbHRzYWx0c2FsdA$a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2V5a2U", "x", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckPassword(tt.hash, tt.password); got != tt.want {
+				t.Errorf("CheckPassword() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestArgon2idNeedsRehash(t *testing.T) {
+	hash, err := testArgon2.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+
+	stronger := testArgon2
+	stronger.Memory *= 2
+	moreIterations := testArgon2
+	moreIterations.Iterations++
+
+	tests := []struct {
+		name   string
+		hasher Argon2idHasher
+		hash   string
+		want   bool
+	}{
+		{"same parameters", testArgon2, hash, false},
+		{"more memory", stronger, hash, true},
+		{"more iterations", moreIterations, hash, true},
+		{"not argon2id", testArgon2, "$2a$10$abcdefghijklmnopqrstuv", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hasher.NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPasswordNeedsRehash(t *testing.T) {
+	bcryptHash, err := BcryptHasher{Cost: bcrypt.MinCost}.Hash("password")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	current, err := HashPassword("password")
+	if err != nil {
+		t.Fatalf("HashPassword: %v", err)
+	}
+
+	// The default is argon2id, so bcrypt hashes are upgraded
+	if !PasswordNeedsRehash(bcryptHash) {
+		t.Error("bcrypt hash does not need a rehash, want true")
+	}
+	if PasswordNeedsRehash(current) {
+		t.Error("hash from the default hasher needs a rehash, want false")
+	}
+}
+
+func TestPasswordInHistory(t *testing.T) {
+	var history []string
+	for _, password := range []string{"first password", "second password"} {
+		hash, err := testArgon2.Hash(password)
+		if err != nil {
+			t.Fatalf("Hash: %v", err)
+		}
+		history = append(history, hash)
+	}
+
+	tests := []struct {
+		password string
+		want     bool
+	}{
+		{"first password", true},
+		{"second password", true},
+		{"third password", false},
+	}
+	for _, tt := range tests {
+		if got := PasswordInHistory(history, tt.password); got != tt.want {
+			t.Errorf("PasswordInHistory(%q) = %v, want %v", tt.password, got, tt.want)
+		}
+	}
+}
+
+func TestDummyPasswordHash(t *testing.T) {
+	hash := DummyPasswordHash()
+	if hash == "" || hashAlgorithm(hash) != HashAlgorithmArgon2id {
+		t.Fatalf("DummyPasswordHash() = %q, want an argon2id hash", hash)
+	}
+	if DummyPasswordHash() != hash {
+		t.Error("DummyPasswordHash() changed between calls")
+	}
+	for _, password := range []string{"", "password", "correct horse"} {
+		if CheckPassword(hash, password) {
+			t.Errorf("dummy hash matches %q", password)
+		}
+	}
+}
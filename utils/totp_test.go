@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+func totpCode(t *testing.T, secret string, step int64) string {
+	t.Helper()
+	code, err := totp.GenerateCodeCustom(secret, time.Unix(step*totpPeriod, 0), totp.ValidateOpts{
+		Period:    totpPeriod,
+		Digits:    otp.DigitsSix,
+		Algorithm: otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		t.Fatalf("GenerateCodeCustom: %v", err)
+	}
+	return code
+}
+
+func TestValidateTOTP(t *testing.T) {
+	key, err := GenerateTOTPKey("HR Backend System", "john@example.com")
+	if err != nil {
+		t.Fatalf("GenerateTOTPKey: %v", err)
+	}
+	now := time.Now().Unix() / totpPeriod
+
+	tests := []struct {
+		name     string
+		code     string
+		lastStep int64
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current code", totpCode(t, key.Secret, now), 0, now, true},
+		{"with spaces", " " + totpCode(t, key.Secret, now) + " ", 0, now, true},
+		{"previous period", totpCode(t, key.Secret, now-1), 0, now - 1, true},
+		{"replayed code", totpCode(t, key.Secret, now), now, 0, false},
+		{"older than last step", totpCode(t, key.Secret, now-1), now - 1, 0, false},
+		{"outside the skew", totpCode(t, key.Secret, now-3), 0, 0, false},
+		{"wrong code", "000000", 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The wrong code may match by chance, skip rather than flake
+			if tt.name == "wrong code" && tt.code == totpCode(t, key.Secret, now) {
+				t.Skip("random secret produced the test code")
+			}
+			step, ok := ValidateTOTP(key.Secret, tt.code, tt.lastStep)
+			if ok != tt.wantOK || (ok && step != tt.wantStep) {
+				t.Errorf("ValidateTOTP() = %d, %v, want %d, %v", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(10)
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes: %v", err)
+	}
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if len(code) != 11 || code[5] != '-' {
+			t.Errorf("code %q is not formatted as xxxxx-xxxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("code %q generated twice", code)
+		}
+		seen[code] = true
+	}
+
+	tests := []struct {
+		in, want string
+	}{
+		{"abcde-fghij", "abcdefghij"},
+		{"ABCDE-FGHIJ", "abcdefghij"},
+		{" abcde fghij ", "abcdefghij"},
+		{"abcdefghij", "abcdefghij"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeRecoveryCode(tt.in); got != tt.want {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}